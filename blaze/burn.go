@@ -0,0 +1,53 @@
+package blaze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/go-number"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+func LoopBurns(ctx context.Context) {
+	log.Println("Mixin Safe Governance start burns loop")
+	for {
+		_, err := models.CreateBurn(ctx)
+		if err != nil {
+			log.Printf("models.CreateBurn() => %v", err)
+		}
+		err = sendBurns(ctx)
+		if err != nil {
+			log.Printf("blaze.sendBurns() => %v", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func sendBurns(ctx context.Context) error {
+	burns, err := models.ReadBurnsByState(ctx, models.BurnStatePending)
+	if err != nil {
+		return err
+	}
+	mixin := config.AppConfig.Mixin
+	for _, b := range burns {
+		in := &bot.TransferInput{
+			AssetId:     b.AssetID,
+			OpponentKey: b.Address,
+			Amount:      number.FromString(b.Amount),
+			TraceId:     b.BurnID,
+			Memo:        "BURN",
+		}
+		tx, err := bot.CreateTransaction(ctx, in, mixin.ClientID, mixin.SessionID, mixin.PrivateKey, mixin.Pin, mixin.PinToken)
+		if err != nil {
+			return err
+		}
+		err = models.UpdateBurnSent(ctx, b.BurnID, tx.TransactionHash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package blaze
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+const (
+	messageTypeFrostRound      = "FROST_ROUND"
+	messageTypeFrostCommitment = "FROST_COMMITMENT"
+	messageTypeFrostShare      = "FROST_SHARE"
+	messageTypeFrostResult     = "FROST_RESULT"
+)
+
+type frostRoundParticipant struct {
+	Custodian  string `json:"custodian"`
+	Position   int    `json:"position"`
+	Commitment string `json:"commitment,omitempty"`
+}
+
+type frostRoundShare struct {
+	Sender string `json:"sender"`
+	Share  string `json:"share"`
+}
+
+type frostRoundMessage struct {
+	Type         string                   `json:"type"`
+	CeremonyID   string                   `json:"ceremony_id"`
+	Round        int                      `json:"round"`
+	Threshold    int                      `json:"threshold"`
+	Participants []*frostRoundParticipant `json:"participants"`
+	Shares       []*frostRoundShare       `json:"shares,omitempty"`
+}
+
+type frostSubmitMessage struct {
+	Type       string `json:"type"`
+	CeremonyID string `json:"ceremony_id"`
+	Commitment string `json:"commitment"`
+	Proof      string `json:"proof"`
+	Receiver   string `json:"receiver"`
+	Share      string `json:"share"`
+	GroupKey   string `json:"group_key"`
+	Signature  string `json:"signature"`
+}
+
+func LoopCeremonies(ctx context.Context) {
+	log.Println("Mixin Safe Governance start ceremonies loop")
+	for {
+		expired, err := models.ExpireCeremonies(ctx, time.Now())
+		if err != nil {
+			log.Printf("models.ExpireCeremonies() => %v", err)
+		}
+		for _, c := range expired {
+			log.Printf("ceremony %s expired in round %d", c.CeremonyID, c.Round)
+		}
+		epochs, err := models.ExpireCustodianEpochs(ctx, time.Now())
+		if err != nil {
+			log.Printf("models.ExpireCustodianEpochs() => %v", err)
+		}
+		for _, e := range epochs {
+			log.Printf("custodian epoch %d expired", e.Epoch)
+		}
+		err = relayCeremonies(ctx)
+		if err != nil {
+			log.Printf("blaze.relayCeremonies() => %v", err)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// relayCeremonies announces the current round to every participant app, with
+// all the commitments for the shares round and the shares sent to the
+// participant for the results round.
+func relayCeremonies(ctx context.Context) error {
+	ceremonies, err := models.ReadCeremoniesToRelay(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range ceremonies {
+		for _, m := range c.Members {
+			msg := &frostRoundMessage{
+				Type:       messageTypeFrostRound,
+				CeremonyID: c.CeremonyID,
+				Round:      c.Round,
+				Threshold:  c.Threshold,
+			}
+			for _, p := range c.Members {
+				rp := &frostRoundParticipant{Custodian: p.Custodian, Position: p.Position}
+				if c.Round >= models.CeremonyRoundShares {
+					rp.Commitment = p.Commitment
+				}
+				msg.Participants = append(msg.Participants, rp)
+			}
+			if c.Round == models.CeremonyRoundResults {
+				shares, err := models.ReadCeremonyShares(ctx, c.CeremonyID, m.Custodian)
+				if err != nil {
+					return err
+				}
+				for _, s := range shares {
+					msg.Shares = append(msg.Shares, &frostRoundShare{Sender: s.Sender, Share: s.Share})
+				}
+			}
+			err = postCeremonyMessage(ctx, m.AppID, bot.UniqueObjectId(c.CeremonyID, fmt.Sprint(c.Round), m.Custodian), msg)
+			if err != nil {
+				return err
+			}
+		}
+		err = models.UpdateCeremonyRelayed(ctx, c.CeremonyID, c.Round)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postCeremonyMessage(ctx context.Context, appID, messageID string, msg *frostRoundMessage) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	mixin := config.AppConfig.Mixin
+	conversationID := bot.UniqueConversationId(mixin.ClientID, appID)
+	data := base64.RawURLEncoding.EncodeToString(buf)
+	return bot.PostMessage(ctx, conversationID, appID, messageID, "PLAIN_TEXT", data, mixin.ClientID, mixin.SessionID, mixin.PrivateKey)
+}
+
+func handleFrostMessage(ctx context.Context, bm bot.MessageView, data []byte) error {
+	var msg frostSubmitMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return nil
+	}
+	var err error
+	switch msg.Type {
+	case messageTypeFrostCommitment:
+		_, err = models.SubmitCeremonyCommitment(ctx, msg.CeremonyID, bm.UserId, msg.Commitment, msg.Proof)
+	case messageTypeFrostShare:
+		_, err = models.SubmitCeremonyShare(ctx, msg.CeremonyID, bm.UserId, msg.Receiver, msg.Share)
+	case messageTypeFrostResult:
+		_, err = models.SubmitCeremonyResult(ctx, msg.CeremonyID, bm.UserId, msg.GroupKey, msg.Signature)
+	}
+	return err
+}
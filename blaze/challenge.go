@@ -0,0 +1,104 @@
+package blaze
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/go-number"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+const (
+	messageTypeChallenge         = "SAFE_CHALLENGE"
+	messageTypeChallengeResponse = "SAFE_CHALLENGE_RESPONSE"
+)
+
+type challengeMessage struct {
+	Type        string    `json:"type"`
+	ChallengeID string    `json:"challenge_id"`
+	Custodian   string    `json:"custodian"`
+	Message     string    `json:"message"`
+	Deadline    time.Time `json:"deadline"`
+}
+
+type challengeResponseMessage struct {
+	ChallengeID string `json:"challenge_id"`
+	Signature   string `json:"signature"`
+}
+
+func processChallenge(ctx context.Context, transfer *bot.TransferView) error {
+	fee := config.AppConfig.Governance.ChallengeFee
+	if fee == "" || number.FromString(transfer.Amount).Cmp(number.FromString(fee)) != 0 {
+		return refundTransfer(ctx, transfer, models.RefundReasonInvalidAmount)
+	}
+	_, err := models.CreateChallenge(ctx, transfer)
+	if reason := refundReason(err); reason != "" {
+		return refundTransfer(ctx, transfer, reason)
+	}
+	return err
+}
+
+func LoopChallenges(ctx context.Context) {
+	log.Println("Mixin Safe Governance start challenges loop")
+	for {
+		err := sendChallenges(ctx)
+		if err != nil {
+			log.Printf("blaze.sendChallenges() => %v", err)
+		}
+		expired, err := models.ExpireChallenges(ctx, time.Now())
+		if err != nil {
+			log.Printf("models.ExpireChallenges() => %v", err)
+		}
+		for _, c := range expired {
+			log.Printf("challenge %s to %s => %s", c.ChallengeID, c.Custodian, c.State)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func sendChallenges(ctx context.Context) error {
+	challenges, err := models.ReadChallengesByState(ctx, models.ChallengeStatePending, 100)
+	if err != nil {
+		return err
+	}
+	mixin := config.AppConfig.Mixin
+	for _, c := range challenges {
+		msg, err := json.Marshal(&challengeMessage{
+			Type:        messageTypeChallenge,
+			ChallengeID: c.ChallengeID,
+			Custodian:   c.Custodian,
+			Message:     c.Message,
+			Deadline:    c.Deadline,
+		})
+		if err != nil {
+			return err
+		}
+		conversationID := bot.UniqueConversationId(mixin.ClientID, c.AppID)
+		messageID := bot.UniqueObjectId(messageTypeChallenge, c.ChallengeID)
+		data := base64.RawURLEncoding.EncodeToString(msg)
+		err = bot.PostMessage(ctx, conversationID, c.AppID, messageID, "PLAIN_TEXT", data, mixin.ClientID, mixin.SessionID, mixin.PrivateKey)
+		if err != nil {
+			log.Printf("bot.PostMessage(%s, %s) => %v", c.ChallengeID, c.AppID, err)
+			continue
+		}
+		err = models.UpdateChallengeSent(ctx, c.ChallengeID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handleChallengeResponse(ctx context.Context, bm bot.MessageView, data []byte) error {
+	var msg challengeResponseMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return nil
+	}
+	_, err := models.AnswerChallenge(ctx, msg.ChallengeID, bm.UserId, msg.Signature)
+	return err
+}
@@ -0,0 +1,25 @@
+package blaze
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+const messageTypeCustodianApproval = "CUSTODIAN_APPROVAL"
+
+type custodianApprovalMessage struct {
+	Epoch     int64  `json:"epoch"`
+	Approver  string `json:"approver"`
+	Signature string `json:"signature"`
+}
+
+func handleCustodianApproval(ctx context.Context, data []byte) error {
+	var msg custodianApprovalMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return nil
+	}
+	_, err := models.ApproveCustodianEpoch(ctx, msg.Epoch, msg.Approver, msg.Signature)
+	return err
+}
@@ -0,0 +1,42 @@
+package blaze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+const depositsPageLimit = 100
+
+func LoopDeposits(ctx context.Context) {
+	log.Println("Mixin Safe Governance start deposits loop")
+	for {
+		err := finalizeDeposits(ctx)
+		if err != nil {
+			log.Printf("blaze.finalizeDeposits() => %v", err)
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
+func finalizeDeposits(ctx context.Context) error {
+	var after string
+	for {
+		deposits, err := models.ReadDepositsToFinalize(ctx, after, depositsPageLimit)
+		if err != nil {
+			return err
+		}
+		for _, d := range deposits {
+			_, err = models.FinalizeDeposit(ctx, d)
+			if err != nil {
+				log.Printf("models.FinalizeDeposit(%s) => %v", d.TransactionHash, err)
+			}
+		}
+		if len(deposits) < depositsPageLimit {
+			return nil
+		}
+		after = deposits[len(deposits)-1].TransactionHash
+	}
+}
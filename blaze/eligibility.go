@@ -0,0 +1,69 @@
+package blaze
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+func LoopNodesEligibility(ctx context.Context) {
+	log.Println("Mixin Safe Governance start nodes eligibility loop")
+	for {
+		nodes, err := models.CheckNodesEligibility(ctx)
+		if err != nil {
+			log.Printf("models.CheckNodesEligibility() => %v", err)
+		}
+		for _, n := range nodes {
+			log.Printf("safe node %s kernel node %s => %s", n.Custodian, n.KernelID, n.State)
+		}
+		err = notifyIneligibleNodes(ctx)
+		if err != nil {
+			log.Printf("blaze.notifyIneligibleNodes() => %v", err)
+		}
+		time.Sleep(5 * time.Minute)
+	}
+}
+
+func notifyIneligibleNodes(ctx context.Context) error {
+	nodes, err := models.ReadNodesByState(ctx, models.NodeStateIneligible)
+	if err != nil {
+		return err
+	}
+	mixin := config.AppConfig.Mixin
+	for _, n := range nodes {
+		key := "ineligible-notified-" + n.Custodian
+		notified, err := models.ReadProperty(ctx, key)
+		if err != nil {
+			return err
+		} else if notified != "" {
+			continue
+		}
+		operator, err := models.ReadNodeOperator(ctx, n)
+		if err != nil {
+			return err
+		}
+		if operator == "" {
+			log.Printf("safe node %s ineligible without operator", n.Custodian)
+			continue
+		}
+		text := fmt.Sprintf("Safe node %s is ineligible, kernel node %s is no longer accepted with payee %s.", n.Custodian, n.KernelID, n.Payee)
+		conversationID := bot.UniqueConversationId(mixin.ClientID, operator)
+		messageID := bot.UniqueObjectId(key, n.UpdatedAt.String())
+		data := base64.RawURLEncoding.EncodeToString([]byte(text))
+		err = bot.PostMessage(ctx, conversationID, operator, messageID, "PLAIN_TEXT", data, mixin.ClientID, mixin.SessionID, mixin.PrivateKey)
+		if err != nil {
+			return err
+		}
+		err = models.WriteProperty(ctx, key, time.Now().Format(time.RFC3339Nano))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package blaze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+func LoopKernelNodes(ctx context.Context) {
+	log.Println("Mixin Safe Governance start kernel nodes loop")
+	for {
+		events, err := models.SyncKernelNodes(ctx)
+		if err != nil {
+			log.Printf("models.SyncKernelNodes() => %v", err)
+		}
+		err = checkKernelEvents(ctx, events)
+		if err != nil {
+			log.Printf("blaze.checkKernelEvents() => %v", err)
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
+func checkKernelEvents(ctx context.Context, events []*models.KernelNodeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	safe, err := models.ReadNodeSetByKernel(ctx)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		log.Printf("kernel node %s => %s %s %s %d", e.NodeID, e.State, e.Signer, e.Payee, e.Timestamp)
+		if n := safe[e.NodeID]; n != nil && e.State != models.KernelNodeStateAccepted {
+			log.Printf("safe node %s kernel node %s left %s => %s", n.Custodian, e.NodeID, models.KernelNodeStateAccepted, e.State)
+		}
+	}
+	return nil
+}
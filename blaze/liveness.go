@@ -0,0 +1,108 @@
+package blaze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+const (
+	kernelSnapshotsCheckpointKey = "kernel-snapshots-checkpoint"
+	kernelSnapshotsPollLimit     = 500
+
+	messageTypeSafeSignature = "SAFE_SIGNATURE"
+)
+
+type safeSignatureMessage struct {
+	Type      string `json:"type"`
+	Day       string `json:"day"`
+	Signature string `json:"signature"`
+}
+
+func PollKernelSignatures(ctx context.Context) {
+	log.Println("Mixin Safe Governance start kernel signatures poller")
+	for {
+		count, err := pollKernelSignatures(ctx)
+		if err != nil {
+			log.Printf("blaze.pollKernelSignatures() => %v", err)
+		}
+		if err != nil || count < kernelSnapshotsPollLimit {
+			time.Sleep(10 * time.Second)
+		}
+	}
+}
+
+func pollKernelSignatures(ctx context.Context) (int, error) {
+	checkpoint, err := models.ReadProperty(ctx, kernelSnapshotsCheckpointKey)
+	if err != nil {
+		return 0, err
+	}
+	var since uint64
+	if checkpoint == "" {
+		since, err = session.Kernel(ctx).ReadTopology()
+		if err != nil {
+			return 0, err
+		}
+		err = models.WriteProperty(ctx, kernelSnapshotsCheckpointKey, fmt.Sprint(since))
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		since, err = strconv.ParseUint(checkpoint, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	snapshots, err := session.Kernel(ctx).ListSnapshots(since, kernelSnapshotsPollLimit)
+	if err != nil {
+		return 0, err
+	}
+	nodes, err := models.ReadNodeSetByKernel(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range snapshots {
+		if n := nodes[s.Node]; n != nil {
+			_, err = models.RecordLivenessSignature(ctx, n.Custodian, models.LivenessKindKernel, s.Hash, time.Unix(0, int64(s.Timestamp)))
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+	last := snapshots[len(snapshots)-1]
+	err = models.WriteProperty(ctx, kernelSnapshotsCheckpointKey, fmt.Sprint(last.Topology+1))
+	if err != nil {
+		return 0, err
+	}
+	return len(snapshots), nil
+}
+
+// handleSafeSignature counts the Safe liveness reported by the node app that
+// sent the message, messages from other users are ignored. The signature is
+// made by the custodian key over the liveness message of the UTC day.
+func handleSafeSignature(ctx context.Context, bm bot.MessageView, data []byte) error {
+	var msg safeSignatureMessage
+	if json.Unmarshal(data, &msg) != nil || msg.Signature == "" {
+		return nil
+	}
+	nodes, err := models.ReadNodeSet(ctx)
+	if err != nil {
+		return err
+	}
+	n := nodes[bm.UserId]
+	if n == nil {
+		return nil
+	}
+	_, err = models.RecordSafeSignature(ctx, n.Custodian, msg.Day, msg.Signature, bm.CreatedAt)
+	return err
+}
@@ -5,11 +5,16 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"log"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/go-number"
 	"github.com/MixinNetwork/safe/governance/config"
 	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
 )
 
 type mixinBlazeHandler func(ctx context.Context, msg bot.MessageView, clientID string) error
@@ -58,18 +63,100 @@ func handleMessage(ctx context.Context, bc *bot.BlazeClient, bm bot.MessageView)
 		if err != nil {
 			return err
 		}
-		governance := config.AppConfig.Governance
-		if transfer.AssetId != governance.FeeAssetID {
-			return nil
-		}
-		if transfer.Amount != governance.Fee {
+		return handleTransfer(ctx, &transfer)
+	}
+	if bm.Category == "PLAIN_TEXT" {
+		return handleTextMessage(ctx, bm, dataRaw)
+	}
+	return nil
+}
+
+// handleTextMessage dispatches the JSON text messages sent by Safe nodes,
+// invalid messages are logged and dropped instead of being retried.
+func handleTextMessage(ctx context.Context, bm bot.MessageView, data []byte) error {
+	var msg struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(data, &msg) != nil {
+		return nil
+	}
+	var err error
+	switch msg.Type {
+	case messageTypeSafeSignature:
+		err = handleSafeSignature(ctx, bm, data)
+	case messageTypeSlashEvidence:
+		err = handleSlashEvidence(ctx, data)
+	case messageTypeSlashApproval:
+		err = handleSlashApproval(ctx, data)
+	case messageTypeChallengeResponse:
+		err = handleChallengeResponse(ctx, bm, data)
+	case messageTypeFrostCommitment, messageTypeFrostShare, messageTypeFrostResult:
+		err = handleFrostMessage(ctx, bm, data)
+	case messageTypeCustodianApproval:
+		err = handleCustodianApproval(ctx, data)
+	}
+	if serr, ok := err.(*session.Error); ok && serr.Status != http.StatusInternalServerError {
+		log.Printf("blaze.handleTextMessage(%s, %s) => %v", bm.MessageId, msg.Type, err)
+		return nil
+	}
+	return err
+}
+
+var transferMutex sync.Mutex
+
+func handleTransfer(ctx context.Context, transfer *bot.TransferView) error {
+	transferMutex.Lock()
+	defer transferMutex.Unlock()
+
+	if number.FromString(transfer.Amount).Sign() <= 0 {
+		return nil
+	}
+	processed, err := models.CheckSnapshotProcessed(ctx, transfer.SnapshotId)
+	if err != nil || processed {
+		return err
+	}
+	err = processTransfer(ctx, transfer)
+	if err != nil {
+		return err
+	}
+	return models.WriteSnapshotProcessed(ctx, transfer)
+}
+
+func processTransfer(ctx context.Context, transfer *bot.TransferView) error {
+	governance := config.AppConfig.Governance
+	if transfer.AssetId != governance.FeeAssetID {
+		return refundTransfer(ctx, transfer, models.RefundReasonInvalidAsset)
+	}
+	if strings.HasPrefix(transfer.Memo, models.ChallengeMemoPrefix) {
+		return processChallenge(ctx, transfer)
+	}
+	if number.FromString(transfer.Amount).Cmp(number.FromString(governance.Fee)) != 0 {
+		return refundTransfer(ctx, transfer, models.RefundReasonInvalidAmount)
+	}
+	if transfer.Memo == "" {
+		return refundTransfer(ctx, transfer, models.RefundReasonEmptyMemo)
+	}
+	old, err := models.ReadNodeByHash(ctx, transfer.Memo)
+	if err != nil {
+		return err
+	} else if old == nil {
+		return refundTransfer(ctx, transfer, models.RefundReasonUnmatchedPayment)
+	} else if old.AppID.String != "" {
+		fee, err := models.ReadNodeFee(ctx, old.Custodian)
+		if err != nil {
+			return err
+		} else if fee == nil {
+			return models.WriteNodeFee(ctx, old.Custodian, transfer)
+		} else if fee.SnapshotID == transfer.SnapshotId {
 			return nil
 		}
-		if transfer.Memo == "" {
-			return nil // TODO deposit to the bot will error
-		}
-		_, err = models.PaymentNode(ctx, transfer.Memo)
-		return err
+		return refundTransfer(ctx, transfer, models.RefundReasonDuplicatePayment)
+	} else if old.State != models.NodeStateRegistered {
+		return refundTransfer(ctx, transfer, models.RefundReasonInactiveNode)
 	}
-	return nil
+	_, err = models.PaymentNode(ctx, transfer)
+	if reason := refundReason(err); reason != "" {
+		return refundTransfer(ctx, transfer, reason)
+	}
+	return err
 }
@@ -0,0 +1,23 @@
+package blaze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+func LoopNodeRegistrations(ctx context.Context) {
+	log.Println("Mixin Safe Governance start node registrations loop")
+	for {
+		nodes, err := models.ExpireNodes(ctx, time.Now())
+		if err != nil {
+			log.Printf("models.ExpireNodes() => %v", err)
+		}
+		for _, n := range nodes {
+			log.Printf("safe node %s registration => %s", n.Custodian, n.State)
+		}
+		time.Sleep(time.Minute)
+	}
+}
@@ -0,0 +1,70 @@
+package blaze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+const (
+	snapshotsCheckpointKey = "snapshots-checkpoint"
+	snapshotsPollLimit     = 500
+)
+
+func PollSnapshots(ctx context.Context) {
+	log.Println("Mixin Safe Governance start snapshots poller")
+	for {
+		count, err := pollSnapshots(ctx)
+		if err != nil {
+			log.Printf("blaze.pollSnapshots() => %v", err)
+		}
+		if err != nil || count < snapshotsPollLimit {
+			time.Sleep(5 * time.Second)
+		}
+	}
+}
+
+func pollSnapshots(ctx context.Context) (int, error) {
+	checkpoint, err := models.ReadProperty(ctx, snapshotsCheckpointKey)
+	if err != nil {
+		return 0, err
+	}
+	mixin := config.AppConfig.Mixin
+	snapshots, err := bot.Snapshots(ctx, snapshotsPollLimit, checkpoint, "", "ASC", mixin.ClientID, mixin.SessionID, mixin.PrivateKey)
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range snapshots {
+		if s.Type == "transfer" && s.OpponentId != mixin.ClientID {
+			transfer := &bot.TransferView{
+				Type:          s.Type,
+				SnapshotId:    s.SnapshotId,
+				CounterUserId: s.OpponentId,
+				AssetId:       s.AssetId,
+				Amount:        s.Amount,
+				TraceId:       s.TraceId,
+				Memo:          s.Memo,
+				CreatedAt:     s.CreatedAt,
+			}
+			err = handleTransfer(ctx, transfer)
+			if err != nil {
+				failure, ferr := models.RecordSnapshotFailure(ctx, transfer, err)
+				if ferr != nil {
+					return 0, ferr
+				} else if !failure.Skipped {
+					return 0, err
+				}
+				log.Printf("blaze.handleTransfer(%s) skipped after %d attempts => %v", s.SnapshotId, failure.Attempts, err)
+			}
+		}
+		err = models.WriteProperty(ctx, snapshotsCheckpointKey, s.CreatedAt.Format(time.RFC3339Nano))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(snapshots), nil
+}
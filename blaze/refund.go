@@ -0,0 +1,78 @@
+package blaze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/go-number"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+func LoopRefunds(ctx context.Context) {
+	log.Println("Mixin Safe Governance start refunds loop")
+	for {
+		err := sendRefunds(ctx)
+		if err != nil {
+			log.Printf("blaze.sendRefunds() => %v", err)
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
+
+func sendRefunds(ctx context.Context) error {
+	refunds, err := models.ReadRefunds(ctx, models.RefundStatePending, 100)
+	if err != nil {
+		return err
+	}
+	mixin := config.AppConfig.Mixin
+	for _, r := range refunds {
+		in := &bot.TransferInput{
+			AssetId:     r.AssetID,
+			RecipientId: r.OpponentID,
+			Amount:      number.FromString(r.Amount),
+			TraceId:     r.TraceID,
+			Memo:        r.Memo,
+		}
+		_, err := bot.CreateTransfer(ctx, in, mixin.ClientID, mixin.SessionID, mixin.PrivateKey, mixin.Pin, mixin.PinToken)
+		if err != nil {
+			log.Printf("bot.CreateTransfer(%s) => %v", r.TraceID, err)
+			continue
+		}
+		err = models.UpdateRefundSent(ctx, r.TraceID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func refundTransfer(ctx context.Context, transfer *bot.TransferView, reason string) error {
+	if transfer.CounterUserId == "" {
+		log.Printf("blaze.refundTransfer(%s, %s) => no opponent", transfer.SnapshotId, reason)
+		return nil
+	}
+	_, err := models.CreateRefund(ctx, transfer, reason)
+	return err
+}
+
+func refundReason(err error) string {
+	serr, ok := err.(*session.Error)
+	if !ok {
+		return ""
+	}
+	switch serr.Code {
+	case 10002:
+		return models.RefundReasonInvalidPayment
+	case 10005:
+		return models.RefundReasonNoSeatsLeft
+	case 10006:
+		return models.RefundReasonRegistrationClosed
+	case 10007:
+		return models.RefundReasonInvalidChallenge
+	}
+	return ""
+}
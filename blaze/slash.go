@@ -0,0 +1,45 @@
+package blaze
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+const (
+	messageTypeSlashEvidence = "SLASH_EVIDENCE"
+	messageTypeSlashApproval = "SLASH_APPROVAL"
+)
+
+type slashEvidenceMessage struct {
+	EvidenceType string `json:"evidence_type"`
+	Custodian    string `json:"custodian"`
+	Reporter     string `json:"reporter"`
+	Payload      string `json:"payload"`
+	Signature    string `json:"signature"`
+}
+
+type slashApprovalMessage struct {
+	ProposalID string `json:"proposal_id"`
+	Approver   string `json:"approver"`
+	Signature  string `json:"signature"`
+}
+
+func handleSlashEvidence(ctx context.Context, data []byte) error {
+	var msg slashEvidenceMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return nil
+	}
+	_, err := models.CreateEvidence(ctx, msg.EvidenceType, msg.Custodian, msg.Reporter, msg.Payload, msg.Signature)
+	return err
+}
+
+func handleSlashApproval(ctx context.Context, data []byte) error {
+	var msg slashApprovalMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return nil
+	}
+	_, err := models.ApproveSlashProposal(ctx, msg.ProposalID, msg.Approver, msg.Signature)
+	return err
+}
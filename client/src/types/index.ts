@@ -6,6 +6,7 @@ export interface NodeResponse {
   keystore: string;
   public_key: string;
   mixin_hash: string;
+  state: string;
   created_at: string;
   updated_at: string;
 }
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+	"github.com/urfave/cli/v2"
+)
+
+func commandContext(c *cli.Context) (context.Context, *store.Database, error) {
+	err := config.InitConfiguration(c.String("config"), c.String("environment"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	database, err := store.OpenDatabase()
+	if err != nil {
+		return nil, nil, err
+	}
+	return session.WithDatabase(c.Context, database), database, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/store"
+	"github.com/urfave/cli/v2"
+)
+
+func DBMigrateCMD(c *cli.Context) error {
+	err := config.InitConfiguration(c.String("config"), c.String("environment"))
+	if err != nil {
+		return err
+	}
+
+	database, err := store.OpenDatabaseWithoutMigration()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	applied, err := database.Migrate(c.Context)
+	for _, m := range applied {
+		log.Printf("Applied migration %s", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		log.Println("Database is up to date")
+	}
+	return nil
+}
+
+func DBStatusCMD(c *cli.Context) error {
+	err := config.InitConfiguration(c.String("config"), c.String("environment"))
+	if err != nil {
+		return err
+	}
+
+	database, err := store.OpenDatabaseWithoutMigration()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrations, err := database.MigrationStatus(c.Context)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		state := "pending"
+		if m.AppliedAt.Valid {
+			state = m.AppliedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-40s %s\n", m.Name, state)
+	}
+	return nil
+}
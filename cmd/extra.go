@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/urfave/cli/v2"
+)
+
+func ExtraBuildCMD(c *cli.Context) error {
+	e, err := buildUnsignedExtra(c)
+	if err != nil {
+		return err
+	}
+	for _, role := range extra.Roles {
+		key, err := readKeyFile(c.String(role + "-key"))
+		if err != nil {
+			return fmt.Errorf("invalid %s key => %v", role, err)
+		}
+		err = checkRoleKey(e, role, key)
+		if err != nil {
+			return err
+		}
+		err = e.Sign(role, key)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Println(e.String())
+	return nil
+}
+
+func ExtraVerifyCMD(c *cli.Context) error {
+	e, err := extra.DecodeString(c.String("extra"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version:\t%d\n", e.Version)
+	fmt.Printf("custodian:\t%s\n", e.Custodian.String())
+	fmt.Printf("payee:\t\t%s\n", e.Payee.String())
+	fmt.Printf("node:\t\t%s\n", e.NodeID.String())
+	if e.Version == extra.Version2 {
+		fmt.Printf("network:\t%s\n", e.NetworkID.String())
+		fmt.Printf("expire:\t\t%s\n", e.ExpireAt.UTC().Format(time.RFC3339))
+		if network := parseNetworkID(c.String("network")); network != e.NetworkID {
+			return fmt.Errorf("network mismatch %s", network)
+		}
+		if !e.ExpireAt.After(time.Now()) {
+			return fmt.Errorf("extra expired at %s", e.ExpireAt)
+		}
+	}
+
+	nodes, err := externals.NewHTTPKernelClient(c.StringSlice("rpc")).ListAllNodes()
+	if err != nil {
+		return err
+	}
+	signerStr := externals.FindNodeSigner(nodes, e.NodeID.String(), e.Payee.String(), time.Now())
+	if signerStr == "" {
+		return fmt.Errorf("kernel node %s with payee %s not found", e.NodeID, e.Payee)
+	}
+	signer, err := common.NewAddressFromString(signerStr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("signer:\t\t%s\n", signer.String())
+	err = e.Verify(signer)
+	if err != nil {
+		return err
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+func buildUnsignedExtra(c *cli.Context) (*extra.Extra, error) {
+	node, err := crypto.HashFromString(c.String("node"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid node %s", c.String("node"))
+	}
+	custodian, err := common.NewAddressFromString(c.String("custodian"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid custodian %s", c.String("custodian"))
+	}
+	payee, err := common.NewAddressFromString(c.String("payee"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payee %s", c.String("payee"))
+	}
+	switch c.Int("version") {
+	case extra.Version1:
+		return extra.NewExtraV1(custodian, payee, node), nil
+	case extra.Version2:
+		var nonce [16]byte
+		_, err = rand.Read(nonce[:])
+		if err != nil {
+			return nil, err
+		}
+		expire := time.Now().Add(c.Duration("expire"))
+		network := parseNetworkID(c.String("network"))
+		return extra.NewExtraV2(custodian, payee, node, network, expire, nonce), nil
+	default:
+		return nil, fmt.Errorf("invalid version %d", c.Int("version"))
+	}
+}
+
+func parseNetworkID(network string) crypto.Hash {
+	if id, err := crypto.HashFromString(network); err == nil {
+		return id
+	}
+	return extra.NetworkIDForEnvironment(network)
+}
+
+func readKeyFile(path string) (crypto.Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return crypto.Key{}, err
+	}
+	return crypto.KeyFromString(strings.TrimSpace(string(data)))
+}
+
+func checkRoleKey(e *extra.Extra, role string, key crypto.Key) error {
+	var public crypto.Key
+	switch role {
+	case extra.RolePayee:
+		public = e.Payee.PublicSpendKey
+	case extra.RoleCustodian:
+		public = e.Custodian.PublicSpendKey
+	default:
+		return nil
+	}
+	if key.Public() != public {
+		return fmt.Errorf("%s key does not match the %s address", role, role)
+	}
+	return nil
+}
+
+func ExtraBundleCreateCMD(c *cli.Context) error {
+	e, err := buildUnsignedExtra(c)
+	if err != nil {
+		return err
+	}
+	fmt.Println(extra.EncodeBundle(e))
+	return nil
+}
+
+func ExtraBundleSignCMD(c *cli.Context) error {
+	e, err := extra.DecodeBundle(c.String("bundle"))
+	if err != nil {
+		return err
+	}
+	role := c.String("role")
+	key, err := readKeyFile(c.String("key"))
+	if err != nil {
+		return fmt.Errorf("invalid %s key => %v", role, err)
+	}
+	err = checkRoleKey(e, role, key)
+	if err != nil {
+		return err
+	}
+	err = e.Sign(role, key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(extra.EncodeBundle(e))
+	return nil
+}
+
+func ExtraBundleMergeCMD(c *cli.Context) error {
+	var bundles []*extra.Extra
+	for _, b := range c.StringSlice("bundle") {
+		e, err := extra.DecodeBundle(b)
+		if err != nil {
+			return err
+		}
+		bundles = append(bundles, e)
+	}
+	merged, err := extra.MergeBundles(bundles...)
+	if err != nil {
+		return err
+	}
+	if missing := merged.Missing(); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "missing signatures: %s\n", strings.Join(missing, ", "))
+		fmt.Println(extra.EncodeBundle(merged))
+		return nil
+	}
+	fmt.Println(merged.String())
+	return nil
+}
@@ -53,7 +53,7 @@ func MigrateCMD(c *cli.Context) error {
 			return err
 		}
 		key := crypto.KeyMultPubPriv(&publicKey, &custodian)
-		keystoreRaw, err = models.AesDecryptCBC(key.Bytes(), keystoreBuf)
+		keystoreRaw, err = models.DecryptKeystore(key.Bytes(), keystoreBuf)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/urfave/cli/v2"
+)
+
+func PledgeExportCMD(c *cli.Context) error {
+	ctx, database, err := commandContext(c)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	pledge, nodes, err := models.BuildPledge(ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(views.BuildPledgeView(pledge, nodes))
+}
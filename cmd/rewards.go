@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/urfave/cli/v2"
+)
+
+func RewardsExportCMD(c *cli.Context) error {
+	day, err := time.Parse("2006-01-02", c.String("day"))
+	if err != nil {
+		return err
+	}
+	ctx, database, err := commandContext(c)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	table, err := models.BuildRewardTable(ctx, day, c.String("mint"))
+	if err != nil {
+		return err
+	}
+	view := views.BuildRewardTableView(table)
+	switch c.String("format") {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(view)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"payee", "custodian", "kernel_signatures", "safe_signatures", "challenges_answered", "challenges_missed", "weight", "amount"})
+		for _, p := range view.Payouts {
+			w.Write([]string{
+				p.Payee, p.Custodian,
+				fmt.Sprint(p.KernelSignatures), fmt.Sprint(p.SafeSignatures),
+				fmt.Sprint(p.ChallengesAnswered), fmt.Sprint(p.ChallengesMissed),
+				fmt.Sprint(p.Weight), p.Amount,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return fmt.Errorf("invalid format %s", c.String("format"))
+}
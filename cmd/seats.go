@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/urfave/cli/v2"
+)
+
+func SeatsImportCMD(c *cli.Context) error {
+	data, err := os.ReadFile(c.String("file"))
+	if err != nil {
+		return err
+	}
+	var apps []*config.App
+	err = json.Unmarshal(data, &apps)
+	if err != nil {
+		return err
+	}
+	return importSeats(c, apps)
+}
+
+func SeatsAddCMD(c *cli.Context) error {
+	app := &config.App{
+		AppID:      c.String("app"),
+		SessionID:  c.String("session"),
+		PrivateKey: c.String("private"),
+		PinToken:   c.String("pin-token"),
+		Pin:        c.String("pin"),
+	}
+	return importSeats(c, []*config.App{app})
+}
+
+func importSeats(c *cli.Context, apps []*config.App) error {
+	ctx, database, err := commandContext(c)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	seats, err := models.ImportAppSeats(ctx, apps)
+	if err != nil {
+		return err
+	}
+	for _, s := range seats {
+		log.Printf("Imported app seat %d %s", s.Position, s.AppID)
+	}
+	return nil
+}
+
+func SeatsListCMD(c *cli.Context) error {
+	ctx, database, err := commandContext(c)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	seats, err := models.ReadAppSeats(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range seats {
+		state := "free"
+		if s.RetiredAt.Valid {
+			state = "retired"
+		} else if s.Custodian.Valid {
+			state = "reserved " + s.Custodian.String
+		} else if s.Credentials == "" {
+			state = "missing credentials"
+		}
+		fmt.Printf("%-4d %s %s\n", s.Position, s.AppID, state)
+	}
+	return nil
+}
+
+func SeatsRetireCMD(c *cli.Context) error {
+	ctx, database, err := commandContext(c)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	seat, err := models.RetireAppSeat(ctx, c.String("app"))
+	if err != nil {
+		return err
+	}
+	log.Printf("Retired app seat %d %s", seat.Position, seat.AppID)
+	return nil
+}
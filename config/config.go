@@ -1,21 +1,21 @@
 package config
 
 import (
-	_ "embed"
-	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml"
 )
 
 const (
 	BuildVersion = "BUILD_VERSION"
-)
-
-//go:embed config.toml
-var configtoml []byte
 
-//go:embed apps.json
-var appsjson []byte
+	envPrefix = "GOVERNANCE"
+)
 
 type Configuration struct {
 	Database struct {
@@ -29,26 +29,154 @@ type Configuration struct {
 		Pin        string `toml:"pin"`
 	} `toml:"mixin"`
 	Governance struct {
-		FeeAssetID string `toml:"fee-asset-id"`
-		Fee        string `toml:"fee"`
+		FeeAssetID   string   `toml:"fee-asset-id"`
+		Fee          string   `toml:"fee"`
+		AdminToken   string   `toml:"admin-token"`
+		SeatKey      string   `toml:"seat-key"`
+		NetworkID    string   `toml:"network-id"`
+		SlashQuorum  int      `toml:"slash-quorum"`
+		ChallengeFee string   `toml:"challenge-fee"`
+		DomainKey    string   `toml:"domain-key"`
+		Epochs       []*Epoch `toml:"epochs"`
 	} `toml:"governance"`
+	Kernel struct {
+		RPC  []string `toml:"rpc"`
+		Fake bool     `toml:"fake"`
+	} `toml:"kernel"`
 	Environment string `toml:"environment"`
 	Port        string `toml:"port"`
 }
 
 var AppConfig *Configuration
 
-func InitConfiguration(env string) {
+// InitConfiguration reads the env section of the TOML file at path, then
+// overrides every string field with the GOVERNANCE_SECTION_KEY environment
+// variable if present, e.g. GOVERNANCE_MIXIN_PRIVATE_KEY for mixin.private-key.
+func InitConfiguration(path, env string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config %s => %v", path, err)
+	}
 	var cfs map[string]*Configuration
-
-	err := toml.Unmarshal(configtoml, &cfs)
+	err = toml.Unmarshal(data, &cfs)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("parse config %s => %v", path, err)
+	}
+	conf := cfs[env]
+	if conf == nil {
+		return fmt.Errorf("environment %s not found in config %s", env, path)
 	}
-	AppConfig = cfs[env]
-	if AppConfig == nil {
-		panic("Invalid environment")
+	overrideFromEnv(reflect.ValueOf(conf).Elem(), envPrefix)
+	err = conf.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid config %s [%s] => %v", path, env, err)
 	}
+	AppConfig = conf
+	return nil
+}
+
+func (c *Configuration) Validate() error {
+	required := map[string]string{
+		"database.path":           c.Database.Path,
+		"mixin.client-id":         c.Mixin.ClientID,
+		"mixin.session-id":        c.Mixin.SessionID,
+		"mixin.private-key":       c.Mixin.PrivateKey,
+		"mixin.pin-token":         c.Mixin.PinToken,
+		"mixin.pin":               c.Mixin.Pin,
+		"governance.fee-asset-id": c.Governance.FeeAssetID,
+		"governance.fee":          c.Governance.Fee,
+	}
+	var missing []string
+	for k, v := range required {
+		if v == "" {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing fields %s", strings.Join(missing, ", "))
+	}
+	if c.Kernel.Fake && len(c.Kernel.RPC) > 0 {
+		return fmt.Errorf("kernel.fake and kernel.rpc are exclusive")
+	}
+	if id := c.Governance.NetworkID; id != "" && len(id) != 64 {
+		return fmt.Errorf("invalid governance.network-id %s", id)
+	}
+	if key := c.Governance.DomainKey; key != "" && len(key) != 64 {
+		return fmt.Errorf("invalid governance.domain-key %s", key)
+	}
+	if c.Governance.SlashQuorum < 0 {
+		return fmt.Errorf("invalid governance.slash-quorum %d", c.Governance.SlashQuorum)
+	}
+	for i, e := range c.Governance.Epochs {
+		if e.Name == "" || e.Seats <= 0 || !e.Close.After(e.Open) {
+			return fmt.Errorf("invalid governance.epochs %d", i)
+		}
+		if i > 0 && e.Open.Before(c.Governance.Epochs[i-1].Close) {
+			return fmt.Errorf("overlapped governance.epochs %d", i)
+		}
+	}
+	return nil
+}
+
+// CurrentEpoch returns the registration epoch open at t. Registration is
+// unrestricted when no epochs are configured, and then it returns nil.
+func (c *Configuration) CurrentEpoch(t time.Time) (*Epoch, bool) {
+	epochs := c.Governance.Epochs
+	if len(epochs) == 0 {
+		return nil, true
+	}
+	for _, e := range epochs {
+		if !t.Before(e.Open) && t.Before(e.Close) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// SlashThreshold returns the approvals required to finalize a slash proposal
+// among active nodes, two thirds of them plus one if no quorum is configured.
+func (c *Configuration) SlashThreshold(active int) int {
+	if q := c.Governance.SlashQuorum; q > 0 {
+		return q
+	}
+	return active*2/3 + 1
+}
+
+func (c *Configuration) NextEpoch(t time.Time) *Epoch {
+	for _, e := range c.Governance.Epochs {
+		if t.Before(e.Open) {
+			return e
+		}
+	}
+	return nil
+}
+
+func overrideFromEnv(v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("toml")
+		if tag == "" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(strings.ReplaceAll(tag, "-", "_"))
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			overrideFromEnv(field, name)
+		case reflect.String:
+			if value, found := os.LookupEnv(name); found {
+				field.SetString(value)
+			}
+		}
+	}
+}
+
+type Epoch struct {
+	Name  string    `toml:"name"`
+	Open  time.Time `toml:"open"`
+	Close time.Time `toml:"close"`
+	Seats int       `toml:"seats"`
 }
 
 type App struct {
@@ -58,12 +186,3 @@ type App struct {
 	PinToken   string `json:"pin_token"`
 	Pin        string `json:"pin"`
 }
-
-func FetchApps() ([]*App, error) {
-	var apps []*App
-	err := json.Unmarshal(appsjson, &apps)
-	if err != nil {
-		return nil, err
-	}
-	return apps, nil
-}
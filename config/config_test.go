@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"log"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/MixinNetwork/bot-api-go-client"
@@ -15,17 +17,13 @@ import (
 func TestConfig(t *testing.T) {
 	assert := assert.New(t)
 
-	InitConfiguration("test")
+	err := InitConfiguration("config.toml", "test")
+	assert.Nil(err)
 	assert.Equal("/tmp/governance_test.sqlite3", AppConfig.Database.Path)
 	assert.Equal("a9f616af-635a-4047-a32c-526340d3c241", AppConfig.Mixin.ClientID)
 
 	assert.Equal("100", AppConfig.Governance.Fee)
 
-	apps, err := FetchApps()
-	assert.Nil(err)
-	assert.Len(apps, 50)
-	assert.Equal("f857e241-9f04-4c55-b3ca-48bfda6675df", apps[0].AppID)
-
 	key, _ := mixin.KeyFromString("6196d87a2ee934da04f51e21c4542674c7ac9a57bf1eb6a39c7d65ac7318680b")
 
 	tipBody := bot.TipBodyForOwnershipTransfer("f857e241-9f04-4c55-b3ca-48bfda6675df")
@@ -45,3 +43,49 @@ func TestConfig(t *testing.T) {
 	private = ed25519.NewKeyFromSeed(seedBuf)
 	log.Println(hex.EncodeToString(pub), hex.EncodeToString(private))
 }
+
+func TestConfigOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(path, []byte(`
+[staging]
+environment = "staging"
+
+[staging.database]
+path = "/tmp/governance/db.sqlite3"
+
+[staging.mixin]
+client-id = "a9f616bc-635a-4047-a32c-526340d3c241"
+session-id = "3a6eabab-0be3-4995-87e0-1cc7aa836b6d"
+pin-token = "oCjIWLXzHpYXSGybOlXia6SVmJsdcBAYrR86znaZ5g4"
+
+[staging.governance]
+fee = "100"
+`), 0600)
+	assert.Nil(err)
+
+	err = InitConfiguration(path, "prod")
+	assert.ErrorContains(err, "environment prod not found")
+	err = InitConfiguration(path, "staging")
+	assert.ErrorContains(err, "missing fields governance.fee-asset-id, mixin.pin, mixin.private-key")
+
+	t.Setenv("GOVERNANCE_MIXIN_PRIVATE_KEY", "private")
+	t.Setenv("GOVERNANCE_MIXIN_PIN", "123456")
+	t.Setenv("GOVERNANCE_GOVERNANCE_FEE_ASSET_ID", "965e5c6e-434c-3fa9-b780-c50f43cd955c")
+	err = InitConfiguration(path, "staging")
+	assert.Nil(err)
+	assert.Equal("private", AppConfig.Mixin.PrivateKey)
+	assert.Equal("123456", AppConfig.Mixin.Pin)
+	assert.Equal("100", AppConfig.Governance.Fee)
+}
+
+func TestSlashThreshold(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Configuration{}
+	assert.Equal(1, c.SlashThreshold(0))
+	assert.Equal(7, c.SlashThreshold(9))
+	c.Governance.SlashQuorum = 3
+	assert.Equal(3, c.SlashThreshold(9))
+}
@@ -0,0 +1,44 @@
+package externals
+
+import (
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/config"
+)
+
+const RemovedNodeGracePeriod = 7 * 24 * time.Hour
+
+type KernelClient interface {
+	ListAllNodes() ([]*Node, error)
+	ReadTransaction(hash string) (*Transaction, error)
+	ListSnapshots(since uint64, count int) ([]*Snapshot, error)
+	ReadTopology() (uint64, error)
+}
+
+func NewKernelClient() KernelClient {
+	kernel := config.AppConfig.Kernel
+	if kernel.Fake {
+		return NewFakeKernelClient(nil)
+	}
+	return NewHTTPKernelClient(kernel.RPC)
+}
+
+// FindNodeSigner returns the signer of the kernel node with the payee, the node
+// must be ACCEPTED or REMOVED within the grace period.
+func FindNodeSigner(nodes []*Node, id, payee string, now time.Time) string {
+	for _, n := range nodes {
+		if n.Id != id {
+			continue
+		}
+		if n.State == "ACCEPTED" && n.Payee == payee {
+			return n.Signer
+		}
+		if n.State == "REMOVED" && n.Payee == payee {
+			t := time.Unix(0, n.Timestamp)
+			if t.Add(RemovedNodeGracePeriod).After(now) {
+				return n.Signer
+			}
+		}
+	}
+	return ""
+}
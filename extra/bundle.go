@@ -0,0 +1,90 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+var bundleMagic = []byte("MSGB")
+
+// EncodeBundle encodes a partially signed extra so each key holder can sign
+// it independently, the layout is magic || payload || mask || signatures,
+// where the mask bits follow the order of Roles.
+func EncodeBundle(e *Extra) string {
+	buf := append([]byte{}, bundleMagic...)
+	buf = append(buf, e.Payload()...)
+	var mask byte
+	var sigs []byte
+	for i, r := range Roles {
+		if sig := e.Signatures[r]; sig != nil {
+			mask |= 1 << i
+			sigs = append(sigs, sig[:]...)
+		}
+	}
+	buf = append(buf, mask)
+	buf = append(buf, sigs...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func DecodeBundle(s string) (*Extra, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(raw, bundleMagic) {
+		return nil, fmt.Errorf("invalid bundle magic")
+	}
+	raw = raw[len(bundleMagic):]
+	e, err := decodePayload(raw)
+	if err != nil {
+		return nil, err
+	}
+	raw = raw[len(e.Payload()):]
+	if len(raw) < 1 {
+		return nil, fmt.Errorf("invalid bundle size")
+	}
+	mask, sigs := raw[0], raw[1:]
+	for i, r := range Roles {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		if len(sigs) < signatureSize {
+			return nil, fmt.Errorf("invalid bundle size")
+		}
+		var sig crypto.Signature
+		copy(sig[:], sigs[:signatureSize])
+		e.Signatures[r] = &sig
+		sigs = sigs[signatureSize:]
+	}
+	if len(sigs) != 0 || mask>>len(Roles) != 0 {
+		return nil, fmt.Errorf("invalid bundle signatures")
+	}
+	return e, nil
+}
+
+// MergeBundles combines the signatures of bundles over the same payload.
+func MergeBundles(bundles ...*Extra) (*Extra, error) {
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no bundles")
+	}
+	payload := bundles[0].Payload()
+	merged, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bundles {
+		if !bytes.Equal(payload, b.Payload()) {
+			return nil, fmt.Errorf("bundle payload mismatch")
+		}
+		for r, sig := range b.Signatures {
+			if old := merged.Signatures[r]; old != nil && *old != *sig {
+				return nil, fmt.Errorf("conflicting %s signatures", r)
+			}
+			merged.Signatures[r] = sig
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,40 @@
+package extra
+
+import (
+	"filippo.io/edwards25519"
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+const (
+	BurnSpendSeed = "MIXIN-SAFE-GOVERNANCE-BURN-SPEND"
+	BurnViewSeed  = "MIXIN-SAFE-GOVERNANCE-BURN-VIEW"
+)
+
+// BurnAddress is provably unspendable, both keys are hashed to curve points
+// from public seeds, so nobody knows their private keys.
+func BurnAddress() common.Address {
+	return common.Address{
+		PublicSpendKey: hashToPoint(BurnSpendSeed),
+		PublicViewKey:  hashToPoint(BurnViewSeed),
+	}
+}
+
+// hashToPoint hashes the seed with an increasing counter until it decodes to
+// a curve point, then clears the cofactor to land in the prime order group.
+func hashToPoint(seed string) crypto.Key {
+	for i := 0; ; i++ {
+		h := crypto.NewHash(append([]byte(seed), byte(i)))
+		p, err := new(edwards25519.Point).SetBytes(h[:])
+		if err != nil {
+			continue
+		}
+		p.MultByCofactor(p)
+		if p.Equal(edwards25519.NewIdentityPoint()) == 1 {
+			continue
+		}
+		var key crypto.Key
+		copy(key[:], p.Bytes())
+		return key
+	}
+}
@@ -0,0 +1,77 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+var custodianUpdateMagic = []byte("MSGC")
+
+// CustodianUpdate moves the custodian key to a new Safe node set, the payload
+// is magic || network || epoch || previous key || group key || threshold ||
+// count || members, with members sorted by their address encoding. The
+// transaction appends count || (index || signature) for each approval, where
+// index is the position of the approver in the previous members.
+type CustodianUpdate struct {
+	NetworkID   crypto.Hash
+	Epoch       uint64
+	PreviousKey crypto.Key
+	GroupKey    crypto.Key
+	Threshold   uint16
+	Members     []common.Address
+	Signatures  map[uint16]*crypto.Signature
+}
+
+// SortCustodianMembers orders the addresses as they appear in the payload,
+// which is also the order used for the approver indexes.
+func SortCustodianMembers(members []common.Address) []common.Address {
+	sorted := append([]common.Address{}, members...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if c := bytes.Compare(a.PublicSpendKey[:], b.PublicSpendKey[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(a.PublicViewKey[:], b.PublicViewKey[:]) < 0
+	})
+	return sorted
+}
+
+func (u *CustodianUpdate) Payload() []byte {
+	members := SortCustodianMembers(u.Members)
+	buf := append([]byte{}, custodianUpdateMagic...)
+	buf = append(buf, u.NetworkID[:]...)
+	buf = binary.BigEndian.AppendUint64(buf, u.Epoch)
+	buf = append(buf, u.PreviousKey[:]...)
+	buf = append(buf, u.GroupKey[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, u.Threshold)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(members)))
+	for _, m := range members {
+		buf = append(buf, m.PublicSpendKey[:]...)
+		buf = append(buf, m.PublicViewKey[:]...)
+	}
+	return buf
+}
+
+func (u *CustodianUpdate) SigningMessage() []byte {
+	msg := crypto.NewHash(u.Payload())
+	return msg[:]
+}
+
+func (u *CustodianUpdate) Encode() []byte {
+	indexes := make([]int, 0, len(u.Signatures))
+	for i := range u.Signatures {
+		indexes = append(indexes, int(i))
+	}
+	sort.Ints(indexes)
+	buf := u.Payload()
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(indexes)))
+	for _, i := range indexes {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(i))
+		buf = append(buf, u.Signatures[uint16(i)][:]...)
+	}
+	return buf
+}
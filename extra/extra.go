@@ -0,0 +1,228 @@
+package extra
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+const (
+	Version1 = 1
+	Version2 = 2
+
+	DomainTag = "MIXIN SAFE GOVERNANCE REGISTRATION"
+
+	RoleSigner    = "signer"
+	RolePayee     = "payee"
+	RoleCustodian = "custodian"
+
+	addressSize   = 64
+	signatureSize = 64
+	nonceSize     = 16
+	payloadV1Size = 1 + addressSize*2 + 32
+	payloadV2Size = payloadV1Size + 32 + 8 + nonceSize
+)
+
+var Roles = []string{RoleSigner, RolePayee, RoleCustodian}
+
+// Extra is the registration payload signed by the kernel node signer, payee
+// and custodian keys.
+//
+// v1: 1 || custodian || payee || node || signatures
+// v2: 2 || custodian || payee || node || network || expire || nonce || signatures
+//
+// Addresses are encoded as public spend key || public view key, and the v2
+// signatures cover the domain tag followed by the payload. A v2 nonce is used
+// once per network, governance rejects any other payload with the same nonce.
+type Extra struct {
+	Version    byte
+	Custodian  common.Address
+	Payee      common.Address
+	NodeID     crypto.Hash
+	NetworkID  crypto.Hash
+	ExpireAt   time.Time
+	Nonce      [nonceSize]byte
+	Signatures map[string]*crypto.Signature
+}
+
+func NewExtraV1(custodian, payee common.Address, node crypto.Hash) *Extra {
+	return &Extra{
+		Version:    Version1,
+		Custodian:  custodian,
+		Payee:      payee,
+		NodeID:     node,
+		Signatures: make(map[string]*crypto.Signature),
+	}
+}
+
+func NewExtraV2(custodian, payee common.Address, node, network crypto.Hash, expire time.Time, nonce [nonceSize]byte) *Extra {
+	e := NewExtraV1(custodian, payee, node)
+	e.Version = Version2
+	e.NetworkID = network
+	e.ExpireAt = time.Unix(expire.Unix(), 0)
+	e.Nonce = nonce
+	return e
+}
+
+func NetworkIDForEnvironment(env string) crypto.Hash {
+	return crypto.NewHash([]byte(DomainTag + " " + env))
+}
+
+func (e *Extra) Payload() []byte {
+	buf := []byte{e.Version}
+	buf = append(buf, e.Custodian.PublicSpendKey[:]...)
+	buf = append(buf, e.Custodian.PublicViewKey[:]...)
+	buf = append(buf, e.Payee.PublicSpendKey[:]...)
+	buf = append(buf, e.Payee.PublicViewKey[:]...)
+	buf = append(buf, e.NodeID[:]...)
+	if e.Version == Version1 {
+		return buf
+	}
+	buf = append(buf, e.NetworkID[:]...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(e.ExpireAt.Unix()))
+	return append(buf, e.Nonce[:]...)
+}
+
+func (e *Extra) SigningMessage() []byte {
+	if e.Version == Version1 {
+		return e.Payload()
+	}
+	return append([]byte(DomainTag), e.Payload()...)
+}
+
+func (e *Extra) Sign(role string, key crypto.Key) error {
+	if !validRole(role) {
+		return fmt.Errorf("invalid role %s", role)
+	}
+	sig := key.Sign(e.SigningMessage())
+	e.Signatures[role] = &sig
+	return nil
+}
+
+func (e *Extra) Missing() []string {
+	var missing []string
+	for _, r := range Roles {
+		if e.Signatures[r] == nil {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// Verify checks the signatures of all three roles, the signer address comes
+// from the kernel node list.
+func (e *Extra) Verify(signer common.Address) error {
+	if missing := e.Missing(); len(missing) > 0 {
+		return fmt.Errorf("missing %s signature", missing[0])
+	}
+	for _, r := range Roles {
+		if !e.VerifyRole(r, signer) {
+			return fmt.Errorf("invalid %s signature", r)
+		}
+	}
+	return nil
+}
+
+func (e *Extra) VerifyRole(role string, signer common.Address) bool {
+	sig := e.Signatures[role]
+	if sig == nil {
+		return false
+	}
+	var key crypto.Key
+	switch role {
+	case RoleSigner:
+		key = signer.PublicSpendKey
+	case RolePayee:
+		key = e.Payee.PublicSpendKey
+	case RoleCustodian:
+		key = e.Custodian.PublicSpendKey
+	default:
+		return false
+	}
+	return key.Verify(e.SigningMessage(), *sig)
+}
+
+func (e *Extra) Encode() []byte {
+	if missing := e.Missing(); len(missing) > 0 {
+		panic(fmt.Errorf("missing %s signature", missing[0]))
+	}
+	buf := e.Payload()
+	for _, r := range Roles {
+		buf = append(buf, e.Signatures[r][:]...)
+	}
+	return buf
+}
+
+func (e *Extra) String() string {
+	return base64.RawURLEncoding.EncodeToString(e.Encode())
+}
+
+func DecodeString(s string) (*Extra, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(raw)
+}
+
+func Decode(raw []byte) (*Extra, error) {
+	payload, err := decodePayload(raw)
+	if err != nil {
+		return nil, err
+	}
+	sigs := raw[len(payload.Payload()):]
+	if len(sigs) != signatureSize*len(Roles) {
+		return nil, fmt.Errorf("invalid extra size %d", len(raw))
+	}
+	for i, r := range Roles {
+		var sig crypto.Signature
+		copy(sig[:], sigs[i*signatureSize:])
+		payload.Signatures[r] = &sig
+	}
+	return payload, nil
+}
+
+func decodePayload(raw []byte) (*Extra, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty extra")
+	}
+	size := 0
+	switch raw[0] {
+	case Version1:
+		size = payloadV1Size
+	case Version2:
+		size = payloadV2Size
+	default:
+		return nil, fmt.Errorf("invalid extra version %d", raw[0])
+	}
+	if len(raw) < size {
+		return nil, fmt.Errorf("invalid extra size %d", len(raw))
+	}
+	e := &Extra{Version: raw[0], Signatures: make(map[string]*crypto.Signature)}
+	buf := raw[1:size]
+	copy(e.Custodian.PublicSpendKey[:], buf[0:32])
+	copy(e.Custodian.PublicViewKey[:], buf[32:64])
+	copy(e.Payee.PublicSpendKey[:], buf[64:96])
+	copy(e.Payee.PublicViewKey[:], buf[96:128])
+	copy(e.NodeID[:], buf[128:160])
+	if e.Version == Version2 {
+		buf = buf[160:]
+		copy(e.NetworkID[:], buf[:32])
+		e.ExpireAt = time.Unix(int64(binary.BigEndian.Uint64(buf[32:40])), 0)
+		copy(e.Nonce[:], buf[40:])
+	}
+	return e, nil
+}
+
+func validRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
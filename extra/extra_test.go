@@ -0,0 +1,175 @@
+package extra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtra(t *testing.T) {
+	assert := assert.New(t)
+
+	custodian, _ := common.NewAddressFromString("XINJYiri2BU4dLGdsj33C5pvDuhzxK7DmWB9PvABa7u53tCoabApajFRsNTbsLjm2tjPfRQJEN2Awpe8SP3V35CMGRm2A5N1")
+	payee, _ := common.NewAddressFromString("XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4")
+	signer, _ := common.NewAddressFromString("XIN4qtYcAuAsJFnHp61waUheVsiK1byouLqbhrA8VpSQwxHs4z8LPjpFRrx3zdmiXZuFSwJ8CAMCwLkxap1LbRWHk2iVsLyx")
+	node, _ := crypto.HashFromString("394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144")
+	keySigner, _ := crypto.KeyFromString("ed4c90d8a0a34e4a3e564ea1ee5399a14a920a8cc2fdc56be3e5fba88c44350e")
+	keyPayee, _ := crypto.KeyFromString("f38222cdd1c17bbf748afa4b74c829785b4af24ea3b5b2172db04f413adc260c")
+	keyCustodian, _ := crypto.KeyFromString("bdfe0792f1d613d7842587e6bce8a05e549876b5a840c47a0577b0540864ba0e")
+
+	v1 := NewExtraV1(custodian, payee, node)
+	assert.Len(v1.Payload(), 161)
+	assert.Nil(v1.Sign(RoleSigner, keySigner))
+	assert.Nil(v1.Sign(RolePayee, keyPayee))
+	assert.NotNil(v1.Verify(signer))
+	assert.Equal([]string{RoleCustodian}, v1.Missing())
+	assert.Nil(v1.Sign(RoleCustodian, keyCustodian))
+	assert.NotNil(v1.Sign("kernel", keyCustodian))
+	assert.Nil(v1.Verify(signer))
+	assert.Len(v1.Encode(), 353)
+
+	decoded, err := DecodeString(v1.String())
+	assert.Nil(err)
+	assert.Equal(custodian.String(), decoded.Custodian.String())
+	assert.Equal(payee.String(), decoded.Payee.String())
+	assert.Equal(node, decoded.NodeID)
+	assert.Nil(decoded.Verify(signer))
+	assert.NotNil(decoded.Verify(payee))
+
+	expire := time.Now().Add(time.Hour)
+	network := NetworkIDForEnvironment("test")
+	v2 := NewExtraV2(custodian, payee, node, network, expire, [16]byte{1, 2, 3})
+	assert.Len(v2.Payload(), 217)
+	assert.Nil(v2.Sign(RoleSigner, keySigner))
+	assert.Nil(v2.Sign(RolePayee, keyPayee))
+	assert.Nil(v2.Sign(RoleCustodian, keyCustodian))
+	raw := v2.Encode()
+	assert.Len(raw, 409)
+
+	decoded, err = Decode(raw)
+	assert.Nil(err)
+	assert.Equal(byte(Version2), decoded.Version)
+	assert.Equal(network, decoded.NetworkID)
+	assert.Equal(expire.Unix(), decoded.ExpireAt.Unix())
+	assert.Equal(byte(3), decoded.Nonce[2])
+	assert.Nil(decoded.Verify(signer))
+
+	raw[200] ^= 1
+	decoded, err = Decode(raw)
+	assert.Nil(err)
+	assert.NotNil(decoded.Verify(signer))
+	_, err = Decode(raw[:408])
+	assert.NotNil(err)
+	_, err = Decode(append([]byte{3}, raw[1:]...))
+	assert.NotNil(err)
+
+	*v1.Signatures[RoleSigner] = *v2.Signatures[RoleSigner]
+	assert.NotNil(v1.Verify(signer))
+}
+
+func TestBundle(t *testing.T) {
+	assert := assert.New(t)
+
+	custodian, _ := common.NewAddressFromString("XINJYiri2BU4dLGdsj33C5pvDuhzxK7DmWB9PvABa7u53tCoabApajFRsNTbsLjm2tjPfRQJEN2Awpe8SP3V35CMGRm2A5N1")
+	payee, _ := common.NewAddressFromString("XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4")
+	signer, _ := common.NewAddressFromString("XIN4qtYcAuAsJFnHp61waUheVsiK1byouLqbhrA8VpSQwxHs4z8LPjpFRrx3zdmiXZuFSwJ8CAMCwLkxap1LbRWHk2iVsLyx")
+	node, _ := crypto.HashFromString("394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144")
+	keySigner, _ := crypto.KeyFromString("ed4c90d8a0a34e4a3e564ea1ee5399a14a920a8cc2fdc56be3e5fba88c44350e")
+	keyPayee, _ := crypto.KeyFromString("f38222cdd1c17bbf748afa4b74c829785b4af24ea3b5b2172db04f413adc260c")
+	keyCustodian, _ := crypto.KeyFromString("bdfe0792f1d613d7842587e6bce8a05e549876b5a840c47a0577b0540864ba0e")
+
+	unsigned := NewExtraV2(custodian, payee, node, NetworkIDForEnvironment("test"), time.Now().Add(time.Hour), [16]byte{7})
+	bundle := EncodeBundle(unsigned)
+
+	signed := make([]*Extra, 0)
+	for role, key := range map[string]crypto.Key{RoleSigner: keySigner, RolePayee: keyPayee, RoleCustodian: keyCustodian} {
+		b, err := DecodeBundle(bundle)
+		assert.Nil(err)
+		assert.Len(b.Missing(), 3)
+		assert.Nil(b.Sign(role, key))
+		b, err = DecodeBundle(EncodeBundle(b))
+		assert.Nil(err)
+		assert.True(b.VerifyRole(role, signer))
+		signed = append(signed, b)
+	}
+
+	partial, err := MergeBundles(signed[:2]...)
+	assert.Nil(err)
+	assert.Len(partial.Missing(), 1)
+	merged, err := MergeBundles(partial, signed[2])
+	assert.Nil(err)
+	assert.Len(merged.Missing(), 0)
+	assert.Nil(merged.Verify(signer))
+	final, err := DecodeString(merged.String())
+	assert.Nil(err)
+	assert.Nil(final.Verify(signer))
+
+	other := NewExtraV1(custodian, payee, node)
+	_, err = MergeBundles(merged, other)
+	assert.NotNil(err)
+	_, err = DecodeBundle(merged.String())
+	assert.NotNil(err)
+}
+
+func TestPledge(t *testing.T) {
+	assert := assert.New(t)
+
+	custodian, _ := common.NewAddressFromString("XINJYiri2BU4dLGdsj33C5pvDuhzxK7DmWB9PvABa7u53tCoabApajFRsNTbsLjm2tjPfRQJEN2Awpe8SP3V35CMGRm2A5N1")
+	payee, _ := common.NewAddressFromString("XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4")
+	a, _ := crypto.HashFromString("394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144")
+	b, _ := crypto.HashFromString("2b0636403194b897a2d92d54060dd84acab78139626db2d919ce9ca84d64a433")
+
+	p1 := &Pledge{Nodes: []*PledgeNode{{custodian, payee, a}, {payee, custodian, b}}}
+	p2 := &Pledge{Nodes: []*PledgeNode{{payee, custodian, b}, {custodian, payee, a}}}
+	assert.Equal(p1.Encode(), p2.Encode())
+	assert.Equal(p1.Hash(), p2.Hash())
+	assert.Len(p1.Encode(), 4+32+2+2*160)
+
+	decoded, err := DecodePledge(p1.Encode())
+	assert.Nil(err)
+	assert.Len(decoded.Nodes, 2)
+	assert.Equal(b, decoded.Nodes[0].NodeID)
+	assert.Equal(payee.String(), decoded.Nodes[0].Custodian.String())
+	assert.Equal(a, decoded.Nodes[1].NodeID)
+
+	_, err = DecodePledge(p1.Encode()[:100])
+	assert.NotNil(err)
+	_, err = DecodePledge([]byte("MSGX"))
+	assert.NotNil(err)
+}
+
+func TestCustodianUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	a, _ := common.NewAddressFromString("XINJYiri2BU4dLGdsj33C5pvDuhzxK7DmWB9PvABa7u53tCoabApajFRsNTbsLjm2tjPfRQJEN2Awpe8SP3V35CMGRm2A5N1")
+	b, _ := common.NewAddressFromString("XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4")
+
+	u1 := &CustodianUpdate{Epoch: 2, Threshold: 2, Members: []common.Address{a, b}}
+	u2 := &CustodianUpdate{Epoch: 2, Threshold: 2, Members: []common.Address{b, a}}
+	assert.Equal(u1.Payload(), u2.Payload())
+	assert.Equal(u1.SigningMessage(), u2.SigningMessage())
+	assert.Len(u1.Payload(), 4+32+8+32+32+2+2+2*64)
+	assert.Len(u1.Encode(), len(u1.Payload())+2)
+
+	var sig crypto.Signature
+	u1.Signatures = map[uint16]*crypto.Signature{1: &sig, 0: &sig}
+	assert.Len(u1.Encode(), len(u1.Payload())+2+2*66)
+	u1.Epoch = 3
+	assert.NotEqual(u1.Payload(), u2.Payload())
+}
+
+func TestBurnAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	a, b := BurnAddress(), BurnAddress()
+	assert.Equal(a.String(), b.String())
+	assert.NotEqual(a.PublicSpendKey, a.PublicViewKey)
+	decoded, err := common.NewAddressFromString(a.String())
+	assert.Nil(err)
+	assert.Equal(a.PublicSpendKey, decoded.PublicSpendKey)
+	assert.True(a.PublicSpendKey.CheckKey())
+	assert.True(a.PublicViewKey.CheckKey())
+}
@@ -0,0 +1,76 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+const pledgeNodeSize = addressSize*2 + 32
+
+var pledgeMagic = []byte("MSGP")
+
+type PledgeNode struct {
+	Custodian common.Address
+	Payee     common.Address
+	NodeID    crypto.Hash
+}
+
+// Pledge is the custodian pledge of the genesis Safe node set, encoded as
+// magic || network || count || nodes, each node custodian || payee || node id,
+// sorted by the kernel node id so the same set always produces the same extra.
+type Pledge struct {
+	NetworkID crypto.Hash
+	Nodes     []*PledgeNode
+}
+
+func (p *Pledge) Encode() []byte {
+	nodes := append([]*PledgeNode{}, p.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].NodeID[:], nodes[j].NodeID[:]) < 0
+	})
+	buf := append([]byte{}, pledgeMagic...)
+	buf = append(buf, p.NetworkID[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(nodes)))
+	for _, n := range nodes {
+		buf = append(buf, n.Custodian.PublicSpendKey[:]...)
+		buf = append(buf, n.Custodian.PublicViewKey[:]...)
+		buf = append(buf, n.Payee.PublicSpendKey[:]...)
+		buf = append(buf, n.Payee.PublicViewKey[:]...)
+		buf = append(buf, n.NodeID[:]...)
+	}
+	return buf
+}
+
+func (p *Pledge) Hash() crypto.Hash {
+	return crypto.NewHash(p.Encode())
+}
+
+func DecodePledge(raw []byte) (*Pledge, error) {
+	if !bytes.HasPrefix(raw, pledgeMagic) || len(raw) < len(pledgeMagic)+32+2 {
+		return nil, fmt.Errorf("invalid pledge magic")
+	}
+	raw = raw[len(pledgeMagic):]
+	p := &Pledge{}
+	copy(p.NetworkID[:], raw[:32])
+	count := int(binary.BigEndian.Uint16(raw[32:34]))
+	raw = raw[34:]
+	if len(raw) != count*pledgeNodeSize {
+		return nil, fmt.Errorf("invalid pledge size")
+	}
+	for i := 0; i < count; i++ {
+		buf := raw[i*pledgeNodeSize:]
+		n := &PledgeNode{}
+		copy(n.Custodian.PublicSpendKey[:], buf[0:32])
+		copy(n.Custodian.PublicViewKey[:], buf[32:64])
+		copy(n.Payee.PublicSpendKey[:], buf[64:96])
+		copy(n.Payee.PublicViewKey[:], buf[96:128])
+		copy(n.NodeID[:], buf[128:160])
+		p.Nodes = append(p.Nodes, n)
+	}
+	return p, nil
+}
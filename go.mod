@@ -3,6 +3,7 @@ module github.com/MixinNetwork/safe/governance
 go 1.20
 
 require (
+	filippo.io/edwards25519 v1.0.0
 	github.com/MixinNetwork/bot-api-go-client v1.7.3
 	github.com/MixinNetwork/go-number v0.1.0
 	github.com/MixinNetwork/mixin v0.15.1
@@ -13,11 +14,11 @@ require (
 	github.com/stretchr/testify v1.8.4
 	github.com/unrolled/render v1.6.0
 	github.com/urfave/cli/v2 v2.25.7
+	golang.org/x/crypto v0.10.0
 	modernc.org/sqlite v1.23.1
 )
 
 require (
-	filippo.io/edwards25519 v1.0.0 // indirect
 	github.com/MixinNetwork/mobilecoin-account v0.0.3 // indirect
 	github.com/btcsuite/btcutil v1.0.2 // indirect
 	github.com/bwesterb/go-ristretto v1.2.3 // indirect
@@ -47,7 +48,6 @@ require (
 	github.com/vmihailenco/tagparser v0.1.2 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 	github.com/zeebo/blake3 v0.2.3 // indirect
-	golang.org/x/crypto v0.10.0 // indirect
 	golang.org/x/exp v0.0.0-20230626212559-97b1e661b5df // indirect
 	golang.org/x/mod v0.11.0 // indirect
 	golang.org/x/net v0.11.0 // indirect
@@ -7,11 +7,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/MixinNetwork/safe/governance/blaze"
 	"github.com/MixinNetwork/safe/governance/cmd"
 	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/externals"
 	"github.com/MixinNetwork/safe/governance/middlewares"
+	"github.com/MixinNetwork/safe/governance/models"
 	"github.com/MixinNetwork/safe/governance/routes"
 	"github.com/MixinNetwork/safe/governance/session"
 	"github.com/MixinNetwork/safe/governance/store"
@@ -20,6 +23,35 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+var environmentFlag = &cli.StringFlag{
+	Name:    "environment",
+	Aliases: []string{"e"},
+	Value:   "development",
+	Usage:   "The environment of the service",
+}
+
+var configFlag = &cli.StringFlag{
+	Name:    "config",
+	Aliases: []string{"c"},
+	Value:   "config/config.toml",
+	Usage:   "The path of the configuration file",
+}
+
+var networkFlag = &cli.StringFlag{
+	Name:  "network",
+	Value: "prod",
+	Usage: "The network id, or the governance environment to derive it",
+}
+
+var extraPayloadFlags = []cli.Flag{
+	&cli.StringFlag{Name: "node", Required: true, Usage: "The kernel node id"},
+	&cli.StringFlag{Name: "custodian", Required: true, Usage: "The custodian address"},
+	&cli.StringFlag{Name: "payee", Required: true, Usage: "The payee address of the kernel node"},
+	&cli.IntFlag{Name: "version", Value: 2, Usage: "The extra version"},
+	&cli.DurationFlag{Name: "expire", Value: 7 * 24 * time.Hour, Usage: "The extra expiry from now, only for version 2"},
+	networkFlag,
+}
+
 func main() {
 	app := &cli.App{
 		Name:                 "governance",
@@ -31,12 +63,160 @@ func main() {
 				Name:   "http",
 				Usage:  "Run the http service",
 				Action: bootCmd,
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:    "environment",
-						Aliases: []string{"e"},
-						Value:   "development",
-						Usage:   "The environment of the http service",
+				Flags:  []cli.Flag{configFlag, environmentFlag},
+			},
+			{
+				Name:  "db",
+				Usage: "Manage the database schema",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "migrate",
+						Usage:  "Apply all pending schema migrations",
+						Action: cmd.DBMigrateCMD,
+						Flags:  []cli.Flag{configFlag, environmentFlag},
+					},
+					{
+						Name:   "status",
+						Usage:  "List the schema migrations and their state",
+						Action: cmd.DBStatusCMD,
+						Flags:  []cli.Flag{configFlag, environmentFlag},
+					},
+				},
+			},
+			{
+				Name:  "pledge",
+				Usage: "Manage the custodian pledge of the genesis Safe nodes",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "export",
+						Usage:  "Export the pledge extra of all app assigned nodes for signing",
+						Action: cmd.PledgeExportCMD,
+						Flags:  []cli.Flag{configFlag, environmentFlag},
+					},
+				},
+			},
+			{
+				Name:  "rewards",
+				Usage: "Calculate the custodian rewards",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "export",
+						Usage:  "Export the payout table of a day by payee",
+						Action: cmd.RewardsExportCMD,
+						Flags: []cli.Flag{
+							configFlag,
+							environmentFlag,
+							&cli.StringFlag{Name: "day", Required: true, Usage: "The UTC day, e.g. 2023-08-08"},
+							&cli.StringFlag{Name: "mint", Required: true, Usage: "The total mint amount of the day"},
+							&cli.StringFlag{Name: "format", Value: "csv", Usage: "The output format, csv or json"},
+						},
+					},
+				},
+			},
+			{
+				Name:  "seats",
+				Usage: "Manage the encrypted app seat inventory",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "import",
+						Usage:  "Import the apps from a JSON file",
+						Action: cmd.SeatsImportCMD,
+						Flags: []cli.Flag{
+							configFlag,
+							environmentFlag,
+							&cli.StringFlag{
+								Name:     "file",
+								Aliases:  []string{"f"},
+								Required: true,
+								Usage:    "The JSON file of the apps list",
+							},
+						},
+					},
+					{
+						Name:   "add",
+						Usage:  "Add a single app",
+						Action: cmd.SeatsAddCMD,
+						Flags: []cli.Flag{
+							configFlag,
+							environmentFlag,
+							&cli.StringFlag{Name: "app", Required: true, Usage: "The app id"},
+							&cli.StringFlag{Name: "session", Required: true, Usage: "The session id of the app"},
+							&cli.StringFlag{Name: "private", Required: true, Usage: "The session private key of the app"},
+							&cli.StringFlag{Name: "pin-token", Usage: "The pin token of the app"},
+							&cli.StringFlag{Name: "pin", Usage: "The pin of the app"},
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "List the app seats without secrets",
+						Action: cmd.SeatsListCMD,
+						Flags:  []cli.Flag{configFlag, environmentFlag},
+					},
+					{
+						Name:   "retire",
+						Usage:  "Retire a free app seat",
+						Action: cmd.SeatsRetireCMD,
+						Flags: []cli.Flag{
+							configFlag,
+							environmentFlag,
+							&cli.StringFlag{Name: "app", Required: true, Usage: "The app id"},
+						},
+					},
+				},
+			},
+			{
+				Name:  "extra",
+				Usage: "Build and verify the registration extra offline",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "build",
+						Usage:  "Build and sign the registration extra with the key files",
+						Action: cmd.ExtraBuildCMD,
+						Flags: append(extraPayloadFlags,
+							&cli.StringFlag{Name: "signer-key", Required: true, Usage: "The file of the kernel node signer private spend key"},
+							&cli.StringFlag{Name: "payee-key", Required: true, Usage: "The file of the payee private spend key"},
+							&cli.StringFlag{Name: "custodian-key", Required: true, Usage: "The file of the custodian private spend key"},
+						),
+					},
+					{
+						Name:  "bundle",
+						Usage: "Sign the registration extra by separate key holders",
+						Subcommands: []*cli.Command{
+							{
+								Name:   "create",
+								Usage:  "Create an unsigned registration bundle",
+								Action: cmd.ExtraBundleCreateCMD,
+								Flags:  extraPayloadFlags,
+							},
+							{
+								Name:   "sign",
+								Usage:  "Add the signature of one role to the bundle",
+								Action: cmd.ExtraBundleSignCMD,
+								Flags: []cli.Flag{
+									&cli.StringFlag{Name: "bundle", Required: true, Usage: "The registration bundle, base64"},
+									&cli.StringFlag{Name: "role", Required: true, Usage: "The role of the key, signer, payee or custodian"},
+									&cli.StringFlag{Name: "key", Required: true, Usage: "The file of the private spend key of the role"},
+								},
+							},
+							{
+								Name:   "merge",
+								Usage:  "Merge the signed bundles, and output the extra once all signatures present",
+								Action: cmd.ExtraBundleMergeCMD,
+								Flags: []cli.Flag{
+									&cli.StringSliceFlag{Name: "bundle", Required: true, Usage: "The registration bundles, base64"},
+								},
+							},
+						},
+					},
+					{
+						Name:   "verify",
+						Usage:  "Decode the registration extra and verify it against the kernel node list",
+						Action: cmd.ExtraVerifyCMD,
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "extra", Required: true, Usage: "The registration extra, base64"},
+							networkFlag,
+							&cli.StringSliceFlag{Name: "rpc", Usage: "The kernel RPC endpoints"},
+						},
 					},
 				},
 			},
@@ -83,22 +263,53 @@ func main() {
 }
 
 func bootCmd(c *cli.Context) error {
-	env := c.String("environment")
-	config.InitConfiguration(env)
+	err := config.InitConfiguration(c.String("config"), c.String("environment"))
+	if err != nil {
+		return err
+	}
+	if config.AppConfig.Port == "" {
+		return fmt.Errorf("missing port in config %s", c.String("config"))
+	}
 
 	database, err := store.OpenDatabase()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
+	kernel := externals.NewKernelClient()
+
 	ctx := context.Background()
 	ctx = session.WithDatabase(ctx, database)
+	ctx = session.WithKernel(ctx, kernel)
+
+	err = models.SyncAppSeats(ctx)
+	if err != nil {
+		return err
+	}
+	err = models.SyncNodeEpochs(ctx)
+	if err != nil {
+		return err
+	}
+	err = models.SyncNodeFees(ctx)
+	if err != nil {
+		return err
+	}
 	go blaze.Boot(ctx)
+	go blaze.PollSnapshots(ctx)
+	go blaze.LoopRefunds(ctx)
+	go blaze.LoopKernelNodes(ctx)
+	go blaze.LoopNodesEligibility(ctx)
+	go blaze.LoopNodeRegistrations(ctx)
+	go blaze.PollKernelSignatures(ctx)
+	go blaze.LoopChallenges(ctx)
+	go blaze.LoopCeremonies(ctx)
+	go blaze.LoopDeposits(ctx)
+	go blaze.LoopBurns(ctx)
 
 	router := httptreemux.New()
 	routes.RegisterRoutes(router)
 	handler := middlewares.Constraint(router)
-	handler = middlewares.Context(handler, database, render.New())
+	handler = middlewares.Context(handler, database, kernel, render.New())
 	handler = middlewares.Stats(handler)
 
 	log.Printf("Mixin Safe Governance http service start at: http://localhost:%s\n", config.AppConfig.Port)
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+type BundleStatus struct {
+	Extra   *extra.Extra
+	Signed  []string
+	Missing []string
+	Invalid []string
+}
+
+func InspectBundle(ctx context.Context, bundle string) (*BundleStatus, error) {
+	e, err := extra.DecodeBundle(bundle)
+	if err != nil {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "bundle", "invalid", bundle)
+	}
+	signerStr, err := findKernelNodeSigner(ctx, e.NodeID.String(), e.Payee.String())
+	if err != nil {
+		return nil, err
+	} else if signerStr == "" {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "not existing", bundle)
+	}
+	signer, err := common.NewAddressFromString(signerStr)
+	if err != nil {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "invalid", bundle)
+	}
+
+	status := &BundleStatus{Extra: e, Missing: e.Missing()}
+	for _, r := range extra.Roles {
+		if e.Signatures[r] == nil {
+			continue
+		}
+		if e.VerifyRole(r, signer) {
+			status.Signed = append(status.Signed, r)
+		} else {
+			status.Invalid = append(status.Invalid, r)
+		}
+	}
+	return status, nil
+}
+
+func CreateNodeByBundle(ctx context.Context, bundle string) (*Node, error) {
+	e, err := extra.DecodeBundle(bundle)
+	if err != nil {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "bundle", "invalid", bundle)
+	}
+	if missing := e.Missing(); len(missing) > 0 {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "bundle", "missing "+strings.Join(missing, ","), bundle)
+	}
+	return CreateNodeByExtra(ctx, e.String())
+}
@@ -0,0 +1,348 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/go-number"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	BurnStatePending = "pending"
+	BurnStateSent    = "sent"
+)
+
+// NodeFee is the registration fee paid for a node by Payer, it is never
+// refunded and will be burned in the batch of BurnID, which is empty until
+// batched.
+type NodeFee struct {
+	Custodian  string
+	SnapshotID string
+	AssetID    string
+	Amount     string
+	Payer      string
+	BurnID     string
+	CreatedAt  time.Time
+}
+
+type Burn struct {
+	BurnID          string
+	AssetID         string
+	Amount          string
+	Address         string
+	State           string
+	TransactionHash string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	Fees []*NodeFee
+}
+
+type BurnLedger struct {
+	Address       string
+	Fee           string
+	Registrations int
+	Unrecorded    []string
+	Fees          number.Decimal
+	Burned        number.Decimal
+	Pending       number.Decimal
+	Burns         []*Burn
+}
+
+var nodeFeesColumns = []string{"custodian", "snapshot_id", "asset_id", "amount", "payer", "burn_id", "created_at"}
+var burnsColumns = []string{"burn_id", "asset_id", "amount", "address", "state", "transaction_hash", "created_at", "updated_at"}
+
+func (f *NodeFee) values() []any {
+	return []any{f.Custodian, f.SnapshotID, f.AssetID, f.Amount, f.Payer, f.BurnID, f.CreatedAt}
+}
+
+func (b *Burn) values() []any {
+	return []any{b.BurnID, b.AssetID, b.Amount, b.Address, b.State, b.TransactionHash, b.CreatedAt, b.UpdatedAt}
+}
+
+func nodeFeeFromRow(row store.Row) (*NodeFee, error) {
+	var f NodeFee
+	err := row.Scan(&f.Custodian, &f.SnapshotID, &f.AssetID, &f.Amount, &f.Payer, &f.BurnID, &f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &f, err
+}
+
+func burnFromRow(row store.Row) (*Burn, error) {
+	var b Burn
+	err := row.Scan(&b.BurnID, &b.AssetID, &b.Amount, &b.Address, &b.State, &b.TransactionHash, &b.CreatedAt, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &b, err
+}
+
+func writeNodeFee(ctx context.Context, tx *sql.Tx, custodian string, transfer *bot.TransferView) error {
+	fee := &NodeFee{
+		Custodian:  custodian,
+		SnapshotID: transfer.SnapshotId,
+		AssetID:    transfer.AssetId,
+		Amount:     transfer.Amount,
+		Payer:      transfer.CounterUserId,
+		CreatedAt:  time.Now(),
+	}
+	query := store.BuildInsertionSQL("node_fees", nodeFeesColumns) + " ON CONFLICT (custodian) DO NOTHING"
+	_, err := tx.ExecContext(ctx, query, fee.values()...)
+	return err
+}
+
+// WriteNodeFee records the transfer as the fee of the custodian node, unless a
+// fee has been recorded already.
+func WriteNodeFee(ctx context.Context, custodian string, transfer *bot.TransferView) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return writeNodeFee(ctx, tx, custodian, transfer)
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+// SyncNodeFees records the configured fee for the nodes paid before the fees
+// were recorded, their payment snapshots are unknown so the snapshot id is
+// derived from the custodian and the payer is left empty.
+func SyncNodeFees(ctx context.Context) error {
+	governance := config.AppConfig.Governance
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT custodian,created_at FROM nodes WHERE app_id IS NOT NULL AND custodian NOT IN (SELECT custodian FROM node_fees)")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		var fees []*NodeFee
+		for rows.Next() {
+			f := &NodeFee{AssetID: governance.FeeAssetID, Amount: governance.Fee}
+			err = rows.Scan(&f.Custodian, &f.CreatedAt)
+			if err != nil {
+				return err
+			}
+			f.SnapshotID = bot.UniqueObjectId("NODE-FEE", f.Custodian)
+			fees = append(fees, f)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		query := store.BuildInsertionSQL("node_fees", nodeFeesColumns) + " ON CONFLICT (custodian) DO NOTHING"
+		for _, f := range fees {
+			_, err = tx.ExecContext(ctx, query, f.values()...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+func ReadNodeFee(ctx context.Context, custodian string) (*NodeFee, error) {
+	var fee *NodeFee
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM node_fees WHERE custodian=?", strings.Join(nodeFeesColumns, ","))
+		f, err := nodeFeeFromRow(tx.QueryRowContext(ctx, query, custodian))
+		fee = f
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return fee, nil
+}
+
+// CreateBurn batches all the fees not burned yet of the same asset into a burn
+// to the unspendable address, it returns nil if there is nothing to burn.
+func CreateBurn(ctx context.Context) (*Burn, error) {
+	var burn *Burn
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		fees, err := findNodeFees(ctx, tx, "")
+		if err != nil || len(fees) == 0 {
+			return err
+		}
+		var ids []string
+		amount := number.Zero()
+		for _, f := range fees {
+			if f.AssetID != fees[0].AssetID {
+				continue
+			}
+			ids = append(ids, f.SnapshotID)
+			amount = amount.Add(number.FromString(f.Amount))
+		}
+
+		t := time.Now()
+		burn = &Burn{
+			BurnID:    bot.UniqueObjectId(append([]string{"BURN"}, ids...)...),
+			AssetID:   fees[0].AssetID,
+			Amount:    amount.Persist(),
+			Address:   extra.BurnAddress().String(),
+			State:     BurnStatePending,
+			CreatedAt: t,
+			UpdatedAt: t,
+		}
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("burns", burnsColumns), burn.values()...)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE node_fees SET burn_id=? WHERE burn_id='' AND asset_id=?", burn.BurnID, burn.AssetID)
+		if err != nil {
+			return err
+		}
+		burn.Fees, err = findNodeFees(ctx, tx, burn.BurnID)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return burn, nil
+}
+
+func UpdateBurnSent(ctx context.Context, id, hash string) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE burns SET state=?,transaction_hash=?,updated_at=? WHERE burn_id=? AND state=?", BurnStateSent, hash, time.Now(), id, BurnStatePending)
+		return err
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+func ReadBurnsByState(ctx context.Context, state string) ([]*Burn, error) {
+	query := fmt.Sprintf("SELECT %s FROM burns WHERE state=? ORDER BY created_at", strings.Join(burnsColumns, ","))
+	return readBurns(ctx, query, state)
+}
+
+// ReadBurnLedger compares the fees of all paid registrations with the amounts
+// sent to the burn address, every burn lists the nodes whose fees it burned,
+// and the paid nodes without a recorded fee are listed as unrecorded.
+func ReadBurnLedger(ctx context.Context) (*BurnLedger, error) {
+	query := fmt.Sprintf("SELECT %s FROM burns ORDER BY created_at", strings.Join(burnsColumns, ","))
+	burns, err := readBurns(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	ledger := &BurnLedger{
+		Address: extra.BurnAddress().String(),
+		Fee:     config.AppConfig.Governance.Fee,
+		Fees:    number.Zero(),
+		Burned:  number.Zero(),
+		Pending: number.Zero(),
+		Burns:   burns,
+	}
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes WHERE app_id IS NOT NULL").Scan(&ledger.Registrations)
+		if err != nil {
+			return err
+		}
+		missing, err := tx.QueryContext(ctx, "SELECT custodian FROM nodes WHERE app_id IS NOT NULL AND custodian NOT IN (SELECT custodian FROM node_fees) ORDER BY custodian")
+		if err != nil {
+			return err
+		}
+		defer missing.Close()
+		for missing.Next() {
+			var custodian string
+			err = missing.Scan(&custodian)
+			if err != nil {
+				return err
+			}
+			ledger.Unrecorded = append(ledger.Unrecorded, custodian)
+		}
+		if err := missing.Err(); err != nil {
+			return err
+		}
+		rows, err := tx.QueryContext(ctx, "SELECT amount,burn_id FROM node_fees")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var amount, burnID string
+			err = rows.Scan(&amount, &burnID)
+			if err != nil {
+				return err
+			}
+			ledger.Fees = ledger.Fees.Add(number.FromString(amount))
+			if burnID == "" {
+				ledger.Pending = ledger.Pending.Add(number.FromString(amount))
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	for _, b := range burns {
+		switch b.State {
+		case BurnStateSent:
+			ledger.Burned = ledger.Burned.Add(number.FromString(b.Amount))
+		case BurnStatePending:
+			ledger.Pending = ledger.Pending.Add(number.FromString(b.Amount))
+		}
+	}
+	return ledger, nil
+}
+
+func readBurns(ctx context.Context, query string, args ...any) ([]*Burn, error) {
+	var burns []*Burn
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			b, err := burnFromRow(rows)
+			if err != nil {
+				return err
+			}
+			burns = append(burns, b)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, b := range burns {
+			b.Fees, err = findNodeFees(ctx, tx, b.BurnID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return burns, nil
+}
+
+func findNodeFees(ctx context.Context, tx *sql.Tx, burnID string) ([]*NodeFee, error) {
+	query := fmt.Sprintf("SELECT %s FROM node_fees WHERE burn_id=? ORDER BY created_at", strings.Join(nodeFeesColumns, ","))
+	rows, err := tx.QueryContext(ctx, query, burnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fees []*NodeFee
+	for rows.Next() {
+		f, err := nodeFeeFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		fees = append(fees, f)
+	}
+	return fees, rows.Err()
+}
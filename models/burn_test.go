@@ -0,0 +1,101 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBurn(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	burn, err := CreateBurn(ctx)
+	assert.Nil(err)
+	assert.Nil(burn)
+
+	var custodians []string
+	for i := 0; i < 3; i++ {
+		_, custodian := testCustodianKey()
+		custodians = append(custodians, custodian)
+		transfer := &bot.TransferView{
+			SnapshotId: uuid.Must(uuid.NewV4()).String(),
+			AssetId:    "965e5c6e-434c-3fa9-b780-c50f43cd955c",
+			Amount:     "100",
+		}
+		assert.Nil(testNodeFee(ctx, custodian, transfer))
+		assert.Nil(testNodeFee(ctx, custodian, transfer))
+	}
+
+	burn, err = CreateBurn(ctx)
+	assert.Nil(err)
+	assert.Equal("300", burn.Amount)
+	assert.Equal(extra.BurnAddress().String(), burn.Address)
+	assert.Equal(BurnStatePending, burn.State)
+	assert.Len(burn.Fees, 3)
+	empty, err := CreateBurn(ctx)
+	assert.Nil(err)
+	assert.Nil(empty)
+
+	ledger, err := ReadBurnLedger(ctx)
+	assert.Nil(err)
+	assert.Equal("300", ledger.Fees.Persist())
+	assert.Equal("0", ledger.Burned.Persist())
+	assert.Equal("300", ledger.Pending.Persist())
+
+	_, custodian := testCustodianKey()
+	transfer := &bot.TransferView{SnapshotId: uuid.Must(uuid.NewV4()).String(), AssetId: burn.AssetID, Amount: "100"}
+	assert.Nil(testNodeFee(ctx, custodian, transfer))
+	assert.Nil(UpdateBurnSent(ctx, burn.BurnID, "hash"))
+	pending, err := ReadBurnsByState(ctx, BurnStatePending)
+	assert.Nil(err)
+	assert.Len(pending, 0)
+
+	_, err = CreateNode(ctx, custodian, custodian, custodian, "app-"+custodian, custodian)
+	assert.Nil(err)
+	_, unpaid := testCustodianKey()
+	_, err = CreateNode(ctx, unpaid, unpaid, unpaid, "app-"+unpaid, unpaid)
+	assert.Nil(err)
+
+	ledger, err = ReadBurnLedger(ctx)
+	assert.Nil(err)
+	assert.Equal(2, ledger.Registrations)
+	assert.Equal([]string{unpaid}, ledger.Unrecorded)
+	assert.Equal("400", ledger.Fees.Persist())
+	assert.Equal("300", ledger.Burned.Persist())
+	assert.Equal("100", ledger.Pending.Persist())
+	assert.Len(ledger.Burns, 1)
+	assert.Equal("hash", ledger.Burns[0].TransactionHash)
+	for _, f := range ledger.Burns[0].Fees {
+		assert.Contains(custodians, f.Custodian)
+	}
+
+	assert.Nil(SyncNodeFees(ctx))
+	assert.Nil(SyncNodeFees(ctx))
+	fee, err := ReadNodeFee(ctx, unpaid)
+	assert.Nil(err)
+	assert.Equal(bot.UniqueObjectId("NODE-FEE", unpaid), fee.SnapshotID)
+	assert.Equal(config.AppConfig.Governance.Fee, fee.Amount)
+	assert.Equal("", fee.Payer)
+	fee, err = ReadNodeFee(ctx, custodian)
+	assert.Nil(err)
+	assert.Equal(transfer.SnapshotId, fee.SnapshotID)
+	ledger, err = ReadBurnLedger(ctx)
+	assert.Nil(err)
+	assert.Len(ledger.Unrecorded, 0)
+}
+
+func testNodeFee(ctx context.Context, custodian string, transfer *bot.TransferView) error {
+	return session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return writeNodeFee(ctx, tx, custodian, transfer)
+	})
+}
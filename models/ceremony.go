@@ -0,0 +1,589 @@
+package models
+
+import (
+	"context"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	CeremonyStateRunning   = "running"
+	CeremonyStateCompleted = "completed"
+	CeremonyStateExpired   = "expired"
+	CeremonyStateAborted   = "aborted"
+
+	CeremonyRoundCommitments = 1
+	CeremonyRoundShares      = 2
+	CeremonyRoundResults     = 3
+
+	CeremonyRoundTimeout = 30 * time.Minute
+
+	ceremonyDomain        = "SAFE-GOVERNANCE-FROST-DKG"
+	ceremonyProofDomain   = "SAFE-GOVERNANCE-FROST-DKG-POK"
+	ceremonyShareMaxBytes = 1024
+)
+
+type Ceremony struct {
+	CeremonyID   string
+	Threshold    int
+	Participants int
+	Round        int
+	RelayedRound int
+	State        string
+	GroupKey     string
+	Deadline     time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	Members []*CeremonyParticipant
+}
+
+type CeremonyParticipant struct {
+	CeremonyID string
+	Custodian  string
+	AppID      string
+	Position   int
+	Commitment string
+	Proof      string
+	GroupKey   string
+	Signature  string
+	UpdatedAt  time.Time
+}
+
+type CeremonyShare struct {
+	CeremonyID string
+	Sender     string
+	Receiver   string
+	Share      string
+	CreatedAt  time.Time
+}
+
+var ceremoniesColumns = []string{"ceremony_id", "threshold", "participants", "round", "relayed_round", "state", "group_key", "created_at", "updated_at", "deadline"}
+var ceremonyParticipantsColumns = []string{"ceremony_id", "custodian", "app_id", "position", "commitment", "group_key", "signature", "updated_at", "proof"}
+var ceremonySharesColumns = []string{"ceremony_id", "sender", "receiver", "share", "created_at"}
+
+func (c *Ceremony) values() []any {
+	return []any{c.CeremonyID, c.Threshold, c.Participants, c.Round, c.RelayedRound, c.State, c.GroupKey, c.CreatedAt, c.UpdatedAt, c.Deadline}
+}
+
+func (p *CeremonyParticipant) values() []any {
+	return []any{p.CeremonyID, p.Custodian, p.AppID, p.Position, p.Commitment, p.GroupKey, p.Signature, p.UpdatedAt, p.Proof}
+}
+
+func (s *CeremonyShare) values() []any {
+	return []any{s.CeremonyID, s.Sender, s.Receiver, s.Share, s.CreatedAt}
+}
+
+func ceremonyFromRow(row store.Row) (*Ceremony, error) {
+	var c Ceremony
+	err := row.Scan(&c.CeremonyID, &c.Threshold, &c.Participants, &c.Round, &c.RelayedRound, &c.State, &c.GroupKey, &c.CreatedAt, &c.UpdatedAt, &c.Deadline)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &c, err
+}
+
+func ceremonyParticipantFromRow(row store.Row) (*CeremonyParticipant, error) {
+	var p CeremonyParticipant
+	err := row.Scan(&p.CeremonyID, &p.Custodian, &p.AppID, &p.Position, &p.Commitment, &p.GroupKey, &p.Signature, &p.UpdatedAt, &p.Proof)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
+
+func ceremonyShareFromRow(row store.Row) (*CeremonyShare, error) {
+	var s CeremonyShare
+	err := row.Scan(&s.CeremonyID, &s.Sender, &s.Receiver, &s.Share, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &s, err
+}
+
+func CeremonyResultSigningMessage(id, groupKey string) []byte {
+	msg := crypto.NewHash([]byte(ceremonyDomain + id + groupKey))
+	return msg[:]
+}
+
+// CreateCeremony starts a FROST key generation among all active Safe nodes
+// with an app, ordered by custodian, only one ceremony may run at a time.
+func CreateCeremony(ctx context.Context, threshold int) (*Ceremony, error) {
+	var ceremony *Ceremony
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := expireCeremonies(ctx, tx, time.Now())
+		if err != nil {
+			return err
+		}
+		var running int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM ceremonies WHERE state=?", CeremonyStateRunning).Scan(&running)
+		if err != nil {
+			return err
+		} else if running > 0 {
+			return session.InvalidStateTransitionError(ctx, CeremonyStateRunning, CeremonyStateRunning)
+		}
+
+		query := fmt.Sprintf("SELECT %s FROM nodes WHERE state IN ('%s') AND app_id IS NOT NULL ORDER BY custodian", strings.Join(nodesColumns, ","), strings.Join(activeNodeStates, "','"))
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		var nodes []*Node
+		for rows.Next() {
+			n, err := nodeFromRow(rows)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, n)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if threshold == 0 {
+			threshold = len(nodes)*2/3 + 1
+		}
+		if len(nodes) < 2 || threshold < 2 || threshold > len(nodes) {
+			return session.BadDataErrorWithFieldAndData(ctx, "threshold", "invalid", fmt.Sprint(threshold))
+		}
+
+		t := time.Now()
+		ceremony = &Ceremony{
+			CeremonyID:   uuid.Must(uuid.NewV4()).String(),
+			Threshold:    threshold,
+			Participants: len(nodes),
+			Round:        CeremonyRoundCommitments,
+			State:        CeremonyStateRunning,
+			Deadline:     t.Add(CeremonyRoundTimeout),
+			CreatedAt:    t,
+			UpdatedAt:    t,
+		}
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("ceremonies", ceremoniesColumns), ceremony.values()...)
+		if err != nil {
+			return err
+		}
+		for i, n := range nodes {
+			p := &CeremonyParticipant{
+				CeremonyID: ceremony.CeremonyID,
+				Custodian:  n.Custodian,
+				AppID:      n.AppID.String,
+				Position:   i + 1,
+				UpdatedAt:  t,
+			}
+			_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("ceremony_participants", ceremonyParticipantsColumns), p.values()...)
+			if err != nil {
+				return err
+			}
+			ceremony.Members = append(ceremony.Members, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return ceremony, nil
+}
+
+func ReadCeremony(ctx context.Context, id string) (*Ceremony, error) {
+	var ceremony *Ceremony
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		c, err := findCeremony(ctx, tx, id)
+		if err != nil || c == nil {
+			return err
+		}
+		ceremony = c
+		return c.loadMembers(ctx, tx)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return ceremony, nil
+}
+
+func ReadCeremonies(ctx context.Context) ([]*Ceremony, error) {
+	query := fmt.Sprintf("SELECT %s FROM ceremonies ORDER BY created_at DESC LIMIT 100", strings.Join(ceremoniesColumns, ","))
+	return readCeremonies(ctx, query)
+}
+
+// ReadCeremoniesToRelay returns the running ceremonies whose current round has
+// not been announced to the participants yet.
+func ReadCeremoniesToRelay(ctx context.Context) ([]*Ceremony, error) {
+	query := fmt.Sprintf("SELECT %s FROM ceremonies WHERE state=? AND relayed_round<round ORDER BY created_at", strings.Join(ceremoniesColumns, ","))
+	return readCeremonies(ctx, query, CeremonyStateRunning)
+}
+
+func UpdateCeremonyRelayed(ctx context.Context, id string, round int) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE ceremonies SET relayed_round=? WHERE ceremony_id=? AND relayed_round<?", round, id, round)
+		return err
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+func ReadCeremonyShares(ctx context.Context, id, receiver string) ([]*CeremonyShare, error) {
+	var shares []*CeremonyShare
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM ceremony_shares WHERE ceremony_id=? AND receiver=? ORDER BY sender", strings.Join(ceremonySharesColumns, ","))
+		rows, err := tx.QueryContext(ctx, query, id, receiver)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			s, err := ceremonyShareFromRow(rows)
+			if err != nil {
+				return err
+			}
+			shares = append(shares, s)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return shares, nil
+}
+
+// SubmitCeremonyCommitment records the round 1 commitment of the participant
+// app, i.e. threshold hex encoded points of its secret polynomial coefficients,
+// with the hex encoded R || z Schnorr proof of knowledge of the constant term
+// secret a0, where z = k + a0 * c, R = k * G and c is the scalar of the SHA512
+// hash of the proof domain, ceremony id, position, C0 and R. The commitments
+// are kept from the other participants until all of them are submitted.
+func SubmitCeremonyCommitment(ctx context.Context, id, appID, commitment, proof string) (*Ceremony, error) {
+	return updateCeremony(ctx, id, appID, CeremonyRoundCommitments, func(ctx context.Context, tx *sql.Tx, c *Ceremony, p *CeremonyParticipant) error {
+		points, err := parseCeremonyCommitment(commitment, c.Threshold)
+		if err != nil {
+			return session.BadDataErrorWithFieldAndData(ctx, "commitment", err.Error(), commitment)
+		}
+		if !verifyCeremonyProof(c.CeremonyID, p.Position, points[0], proof) {
+			return session.BadDataErrorWithFieldAndData(ctx, "proof", "invalid", proof)
+		}
+		if p.Commitment != "" {
+			return nil
+		}
+		p.Commitment, p.Proof = commitment, proof
+		_, err = tx.ExecContext(ctx, "UPDATE ceremony_participants SET commitment=?,proof=?,updated_at=? WHERE ceremony_id=? AND custodian=?", p.Commitment, p.Proof, time.Now(), c.CeremonyID, p.Custodian)
+		if err != nil {
+			return err
+		}
+		for _, m := range c.Members {
+			if m.Commitment == "" {
+				return nil
+			}
+		}
+		return c.advance(ctx, tx, CeremonyRoundShares)
+	})
+}
+
+// SubmitCeremonyShare records the round 2 share from the participant app to
+// the receiver, the share is encrypted to the receiver and opaque to us.
+func SubmitCeremonyShare(ctx context.Context, id, appID, receiver, share string) (*Ceremony, error) {
+	return updateCeremony(ctx, id, appID, CeremonyRoundShares, func(ctx context.Context, tx *sql.Tx, c *Ceremony, p *CeremonyParticipant) error {
+		buf, err := hex.DecodeString(share)
+		if err != nil || len(buf) == 0 || len(buf) > ceremonyShareMaxBytes {
+			return session.BadDataErrorWithFieldAndData(ctx, "share", "invalid", share)
+		}
+		if c.member(receiver) == nil || receiver == p.Custodian {
+			return session.BadDataErrorWithFieldAndData(ctx, "receiver", "invalid", receiver)
+		}
+		s := &CeremonyShare{CeremonyID: c.CeremonyID, Sender: p.Custodian, Receiver: receiver, Share: share, CreatedAt: time.Now()}
+		query := store.BuildInsertionSQL("ceremony_shares", ceremonySharesColumns) + " ON CONFLICT (ceremony_id, sender, receiver) DO NOTHING"
+		_, err = tx.ExecContext(ctx, query, s.values()...)
+		if err != nil {
+			return err
+		}
+		var count int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM ceremony_shares WHERE ceremony_id=?", c.CeremonyID).Scan(&count)
+		if err != nil || count < c.Participants*(c.Participants-1) {
+			return err
+		}
+		return c.advance(ctx, tx, CeremonyRoundResults)
+	})
+}
+
+// SubmitCeremonyResult records the group public key derived by the participant
+// and its custodian signature of it. The key must equal the sum of all the
+// constant term commitments, and the ceremony completes once all agree.
+func SubmitCeremonyResult(ctx context.Context, id, appID, groupKey, signature string) (*Ceremony, error) {
+	return updateCeremony(ctx, id, appID, CeremonyRoundResults, func(ctx context.Context, tx *sql.Tx, c *Ceremony, p *CeremonyParticipant) error {
+		expected, err := c.expectedGroupKey()
+		if err != nil {
+			return err
+		} else if groupKey != expected {
+			return session.BadDataErrorWithFieldAndData(ctx, "group_key", "mismatch", groupKey)
+		}
+		if !verifyCustodianSignature(p.Custodian, signature, CeremonyResultSigningMessage(c.CeremonyID, groupKey)) {
+			return session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+		}
+		p.GroupKey, p.Signature = groupKey, signature
+		_, err = tx.ExecContext(ctx, "UPDATE ceremony_participants SET group_key=?,signature=?,updated_at=? WHERE ceremony_id=? AND custodian=?", p.GroupKey, p.Signature, time.Now(), c.CeremonyID, p.Custodian)
+		if err != nil {
+			return err
+		}
+		for _, m := range c.Members {
+			if m.Signature == "" {
+				return nil
+			}
+		}
+		c.State, c.GroupKey, c.UpdatedAt = CeremonyStateCompleted, expected, time.Now()
+		_, err = tx.ExecContext(ctx, "UPDATE ceremonies SET state=?,group_key=?,updated_at=? WHERE ceremony_id=?", c.State, c.GroupKey, c.UpdatedAt, c.CeremonyID)
+		return err
+	})
+}
+
+func updateCeremony(ctx context.Context, id, appID string, round int, f func(context.Context, *sql.Tx, *Ceremony, *CeremonyParticipant) error) (*Ceremony, error) {
+	var ceremony *Ceremony
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		c, err := findCeremony(ctx, tx, id)
+		if err != nil {
+			return err
+		} else if c == nil {
+			return session.NotFoundError(ctx)
+		}
+		err = c.loadMembers(ctx, tx)
+		if err != nil {
+			return err
+		}
+		ceremony = c
+		var p *CeremonyParticipant
+		for _, m := range c.Members {
+			if m.AppID == appID {
+				p = m
+			}
+		}
+		if p == nil {
+			return session.ForbiddenError(ctx)
+		}
+		if c.State != CeremonyStateRunning || c.Round != round {
+			return session.InvalidStateTransitionError(ctx, fmt.Sprint(c.Round), fmt.Sprint(round))
+		}
+		if time.Now().After(c.Deadline) {
+			return session.InvalidStateTransitionError(ctx, c.State, CeremonyStateExpired)
+		}
+		return f(ctx, tx, c, p)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return ceremony, nil
+}
+
+func (c *Ceremony) advance(ctx context.Context, tx *sql.Tx, round int) error {
+	c.Round, c.UpdatedAt = round, time.Now()
+	c.Deadline = c.UpdatedAt.Add(CeremonyRoundTimeout)
+	_, err := tx.ExecContext(ctx, "UPDATE ceremonies SET round=?,deadline=?,updated_at=? WHERE ceremony_id=?", c.Round, c.Deadline, c.UpdatedAt, c.CeremonyID)
+	return err
+}
+
+// CommitmentsRevealed reports whether all round 1 commitments are submitted,
+// before that they must not be visible to any participant.
+func (c *Ceremony) CommitmentsRevealed() bool {
+	return c.Round > CeremonyRoundCommitments || c.State == CeremonyStateCompleted
+}
+
+// ExpireCeremonies ends the running ceremonies whose current round is not
+// finished before the deadline, so that a new one can be started.
+func ExpireCeremonies(ctx context.Context, now time.Time) ([]*Ceremony, error) {
+	var ceremonies []*Ceremony
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		expired, err := expireCeremonies(ctx, tx, now)
+		ceremonies = expired
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return ceremonies, nil
+}
+
+func AbortCeremony(ctx context.Context, id string) (*Ceremony, error) {
+	var ceremony *Ceremony
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		c, err := findCeremony(ctx, tx, id)
+		if err != nil {
+			return err
+		} else if c == nil {
+			return session.NotFoundError(ctx)
+		}
+		ceremony = c
+		if c.State != CeremonyStateRunning {
+			return session.InvalidStateTransitionError(ctx, c.State, CeremonyStateAborted)
+		}
+		c.State, c.UpdatedAt = CeremonyStateAborted, time.Now()
+		_, err = tx.ExecContext(ctx, "UPDATE ceremonies SET state=?,updated_at=? WHERE ceremony_id=?", c.State, c.UpdatedAt, c.CeremonyID)
+		if err != nil {
+			return err
+		}
+		return c.loadMembers(ctx, tx)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return ceremony, nil
+}
+
+func expireCeremonies(ctx context.Context, tx *sql.Tx, now time.Time) ([]*Ceremony, error) {
+	query := fmt.Sprintf("SELECT %s FROM ceremonies WHERE state=?", strings.Join(ceremoniesColumns, ","))
+	rows, err := tx.QueryContext(ctx, query, CeremonyStateRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var expired []*Ceremony
+	for rows.Next() {
+		c, err := ceremonyFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if now.After(c.Deadline) {
+			expired = append(expired, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, c := range expired {
+		c.State, c.UpdatedAt = CeremonyStateExpired, now
+		_, err = tx.ExecContext(ctx, "UPDATE ceremonies SET state=?,updated_at=? WHERE ceremony_id=? AND state=?", c.State, c.UpdatedAt, c.CeremonyID, CeremonyStateRunning)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+func (c *Ceremony) member(custodian string) *CeremonyParticipant {
+	for _, m := range c.Members {
+		if m.Custodian == custodian {
+			return m
+		}
+	}
+	return nil
+}
+
+func (c *Ceremony) expectedGroupKey() (string, error) {
+	sum := edwards25519.NewIdentityPoint()
+	for _, m := range c.Members {
+		points, err := parseCeremonyCommitment(m.Commitment, c.Threshold)
+		if err != nil {
+			return "", err
+		}
+		sum = sum.Add(sum, points[0])
+	}
+	return hex.EncodeToString(sum.Bytes()), nil
+}
+
+func (c *Ceremony) loadMembers(ctx context.Context, tx *sql.Tx) error {
+	query := fmt.Sprintf("SELECT %s FROM ceremony_participants WHERE ceremony_id=? ORDER BY position", strings.Join(ceremonyParticipantsColumns, ","))
+	rows, err := tx.QueryContext(ctx, query, c.CeremonyID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	c.Members = nil
+	for rows.Next() {
+		p, err := ceremonyParticipantFromRow(rows)
+		if err != nil {
+			return err
+		}
+		c.Members = append(c.Members, p)
+	}
+	return rows.Err()
+}
+
+func verifyCeremonyProof(id string, position int, c0 *edwards25519.Point, proof string) bool {
+	buf, err := hex.DecodeString(proof)
+	if err != nil || len(buf) != 64 {
+		return false
+	}
+	r, err := new(edwards25519.Point).SetBytes(buf[:32])
+	if err != nil {
+		return false
+	}
+	z, err := edwards25519.NewScalar().SetCanonicalBytes(buf[32:])
+	if err != nil {
+		return false
+	}
+	c := ceremonyProofChallenge(id, position, c0, r)
+	lhs := new(edwards25519.Point).ScalarBaseMult(z)
+	rhs := new(edwards25519.Point).ScalarMult(c, c0)
+	rhs = rhs.Add(rhs, r)
+	return lhs.Equal(rhs) == 1
+}
+
+func ceremonyProofChallenge(id string, position int, c0, r *edwards25519.Point) *edwards25519.Scalar {
+	h := sha512.New()
+	h.Write([]byte(ceremonyProofDomain))
+	h.Write([]byte(id))
+	h.Write([]byte(fmt.Sprint(position)))
+	h.Write(c0.Bytes())
+	h.Write(r.Bytes())
+	c, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	return c
+}
+
+func parseCeremonyCommitment(commitment string, threshold int) ([]*edwards25519.Point, error) {
+	buf, err := hex.DecodeString(commitment)
+	if err != nil || len(buf) != threshold*32 {
+		return nil, fmt.Errorf("invalid size")
+	}
+	points := make([]*edwards25519.Point, threshold)
+	for i := range points {
+		points[i], err = new(edwards25519.Point).SetBytes(buf[i*32 : (i+1)*32])
+		if err != nil {
+			return nil, fmt.Errorf("invalid point %d", i)
+		}
+	}
+	return points, nil
+}
+
+func readCeremonies(ctx context.Context, query string, args ...any) ([]*Ceremony, error) {
+	var ceremonies []*Ceremony
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			c, err := ceremonyFromRow(rows)
+			if err != nil {
+				return err
+			}
+			ceremonies = append(ceremonies, c)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, c := range ceremonies {
+			err = c.loadMembers(ctx, tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return ceremonies, nil
+}
+
+func findCeremony(ctx context.Context, tx *sql.Tx, id string) (*Ceremony, error) {
+	query := fmt.Sprintf("SELECT %s FROM ceremonies WHERE ceremony_id=?", strings.Join(ceremoniesColumns, ","))
+	return ceremonyFromRow(tx.QueryRowContext(ctx, query, id))
+}
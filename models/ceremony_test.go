@@ -0,0 +1,174 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCeremony(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	keys := make(map[string]crypto.Key)
+	for i := 0; i < 3; i++ {
+		key, custodian := testCustodianKey()
+		keys[custodian] = key
+		_, err := CreateNode(ctx, custodian, custodian, custodian, fmt.Sprintf("app-%s", custodian), custodian)
+		assert.Nil(err)
+	}
+
+	_, err := CreateCeremony(ctx, 4)
+	assert.NotNil(err)
+	ceremony, err := CreateCeremony(ctx, 2)
+	assert.Nil(err)
+	assert.Len(ceremony.Members, 3)
+	assert.Equal(CeremonyRoundCommitments, ceremony.Round)
+	_, err = CreateCeremony(ctx, 2)
+	assert.NotNil(err)
+	relay, err := ReadCeremoniesToRelay(ctx)
+	assert.Nil(err)
+	assert.Len(relay, 1)
+	assert.Nil(UpdateCeremonyRelayed(ctx, ceremony.CeremonyID, ceremony.Round))
+	relay, err = ReadCeremoniesToRelay(ctx)
+	assert.Nil(err)
+	assert.Len(relay, 0)
+
+	id := ceremony.CeremonyID
+	commitment, proof := testCeremonyCommitment(id, 1, 2)
+	_, err = SubmitCeremonyCommitment(ctx, id, "unknown", commitment, proof)
+	assert.NotNil(err)
+	commitment, proof = testCeremonyCommitment(id, 1, 3)
+	_, err = SubmitCeremonyCommitment(ctx, id, ceremony.Members[0].AppID, commitment, proof)
+	assert.NotNil(err)
+	commitment, proof = testCeremonyCommitment(id, 2, 2)
+	_, err = SubmitCeremonyCommitment(ctx, id, ceremony.Members[0].AppID, commitment, proof)
+	assert.NotNil(err)
+	other, _ := testCeremonyCommitment(id, 1, 2)
+	_, err = SubmitCeremonyCommitment(ctx, id, ceremony.Members[0].AppID, other, proof)
+	assert.NotNil(err)
+	for _, m := range ceremony.Members {
+		assert.False(ceremony.CommitmentsRevealed())
+		commitment, proof := testCeremonyCommitment(id, m.Position, 2)
+		c, err := SubmitCeremonyCommitment(ctx, id, m.AppID, commitment, proof)
+		assert.Nil(err)
+		ceremony = c
+	}
+	assert.Equal(CeremonyRoundShares, ceremony.Round)
+	assert.True(ceremony.CommitmentsRevealed())
+
+	for _, m := range ceremony.Members {
+		_, err = SubmitCeremonyShare(ctx, id, m.AppID, m.Custodian, "00")
+		assert.NotNil(err)
+		for _, r := range ceremony.Members {
+			if r.Custodian == m.Custodian {
+				continue
+			}
+			c, err := SubmitCeremonyShare(ctx, id, m.AppID, r.Custodian, "0102")
+			assert.Nil(err)
+			ceremony = c
+		}
+	}
+	assert.Equal(CeremonyRoundResults, ceremony.Round)
+	shares, err := ReadCeremonyShares(ctx, id, ceremony.Members[0].Custodian)
+	assert.Nil(err)
+	assert.Len(shares, 2)
+
+	groupKey, err := ceremony.expectedGroupKey()
+	assert.Nil(err)
+	m := ceremony.Members[0]
+	key := keys[m.Custodian]
+	sig := key.Sign(CeremonyResultSigningMessage(id, groupKey))
+	_, err = SubmitCeremonyResult(ctx, id, m.AppID, hex.EncodeToString(make([]byte, 32)), sig.String())
+	assert.NotNil(err)
+	_, err = SubmitCeremonyResult(ctx, id, m.AppID, groupKey, "00"+sig.String()[2:])
+	assert.NotNil(err)
+	for _, m := range ceremony.Members {
+		key := keys[m.Custodian]
+		sig := key.Sign(CeremonyResultSigningMessage(id, groupKey))
+		c, err := SubmitCeremonyResult(ctx, id, m.AppID, groupKey, sig.String())
+		assert.Nil(err)
+		ceremony = c
+	}
+	assert.Equal(CeremonyStateCompleted, ceremony.State)
+	assert.Equal(groupKey, ceremony.GroupKey)
+
+	ceremony, err = ReadCeremony(ctx, id)
+	assert.Nil(err)
+	assert.Equal(CeremonyStateCompleted, ceremony.State)
+	for _, m := range ceremony.Members {
+		assert.Equal(groupKey, m.GroupKey)
+	}
+}
+
+func TestCeremonyExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	for i := 0; i < 3; i++ {
+		_, custodian := testCustodianKey()
+		_, err := CreateNode(ctx, custodian, custodian, custodian, fmt.Sprintf("app-%s", custodian), custodian)
+		assert.Nil(err)
+	}
+
+	ceremony, err := CreateCeremony(ctx, 2)
+	assert.Nil(err)
+	expired, err := ExpireCeremonies(ctx, time.Now())
+	assert.Nil(err)
+	assert.Len(expired, 0)
+	expired, err = ExpireCeremonies(ctx, ceremony.Deadline.Add(time.Second))
+	assert.Nil(err)
+	assert.Len(expired, 1)
+	ceremony, err = ReadCeremony(ctx, ceremony.CeremonyID)
+	assert.Nil(err)
+	assert.Equal(CeremonyStateExpired, ceremony.State)
+	_, err = AbortCeremony(ctx, ceremony.CeremonyID)
+	assert.NotNil(err)
+
+	ceremony, err = CreateCeremony(ctx, 2)
+	assert.Nil(err)
+	ceremony, err = AbortCeremony(ctx, ceremony.CeremonyID)
+	assert.Nil(err)
+	assert.Equal(CeremonyStateAborted, ceremony.State)
+	commitment, proof := testCeremonyCommitment(ceremony.CeremonyID, 1, 2)
+	_, err = SubmitCeremonyCommitment(ctx, ceremony.CeremonyID, ceremony.Members[0].AppID, commitment, proof)
+	assert.NotNil(err)
+	_, err = CreateCeremony(ctx, 2)
+	assert.Nil(err)
+}
+
+func testCeremonyCommitment(id string, position, threshold int) (string, string) {
+	var buf []byte
+	var secret *edwards25519.Scalar
+	for i := 0; i < threshold; i++ {
+		s := testCeremonyScalar()
+		if i == 0 {
+			secret = s
+		}
+		buf = append(buf, new(edwards25519.Point).ScalarBaseMult(s).Bytes()...)
+	}
+	c0 := new(edwards25519.Point).ScalarBaseMult(secret)
+	k := testCeremonyScalar()
+	r := new(edwards25519.Point).ScalarBaseMult(k)
+	c := ceremonyProofChallenge(id, position, c0, r)
+	z := edwards25519.NewScalar().MultiplyAdd(secret, c, k)
+	proof := append(r.Bytes(), z.Bytes()...)
+	return hex.EncodeToString(buf), hex.EncodeToString(proof)
+}
+
+func testCeremonyScalar() *edwards25519.Scalar {
+	seed := make([]byte, 64)
+	rand.Read(seed)
+	s, _ := edwards25519.NewScalar().SetUniformBytes(seed)
+	return s
+}
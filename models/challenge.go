@@ -0,0 +1,264 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	ChallengeMemoPrefix = "CHALLENGE:"
+	ChallengeTimeout    = 10 * time.Minute
+
+	ChallengeStatePending     = "pending"
+	ChallengeStateSent        = "sent"
+	ChallengeStateAnswered    = "answered"
+	ChallengeStateExpired     = "expired"
+	ChallengeStateUndelivered = "undelivered"
+
+	challengeDomain = "SAFE-GOVERNANCE-CHALLENGE"
+)
+
+type Challenge struct {
+	ChallengeID string
+	SnapshotID  string
+	Challenger  string
+	Custodian   string
+	AppID       string
+	Message     string
+	Signature   string
+	State       string
+	Deadline    time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+var challengesColumns = []string{"challenge_id", "snapshot_id", "challenger", "custodian", "app_id", "message", "signature", "state", "deadline", "created_at", "updated_at"}
+
+func (c *Challenge) values() []any {
+	return []any{c.ChallengeID, c.SnapshotID, c.Challenger, c.Custodian, c.AppID, c.Message, c.Signature, c.State, c.Deadline, c.CreatedAt, c.UpdatedAt}
+}
+
+func challengeFromRow(row store.Row) (*Challenge, error) {
+	var c Challenge
+	err := row.Scan(&c.ChallengeID, &c.SnapshotID, &c.Challenger, &c.Custodian, &c.AppID, &c.Message, &c.Signature, &c.State, &c.Deadline, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &c, err
+}
+
+func ChallengeSigningMessage(id, message string) []byte {
+	msg := crypto.NewHash([]byte(challengeDomain + id + message))
+	return msg[:]
+}
+
+// CreateChallenge records the challenge paid by the transfer, the memo is the
+// prefix followed by the custodian of an active Safe node with an app.
+func CreateChallenge(ctx context.Context, transfer *bot.TransferView) (*Challenge, error) {
+	custodian := strings.TrimPrefix(transfer.Memo, ChallengeMemoPrefix)
+	node, err := ReadNode(ctx, custodian)
+	if err != nil {
+		return nil, err
+	} else if node == nil || node.Custodian != custodian || !node.AppID.Valid {
+		return nil, session.InvalidChallengeError(ctx)
+	}
+	active, err := checkActiveNode(ctx, custodian)
+	if err != nil {
+		return nil, err
+	} else if !active {
+		return nil, session.InvalidChallengeError(ctx)
+	}
+
+	nonce := make([]byte, 32)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now()
+	challenge := &Challenge{
+		ChallengeID: bot.UniqueObjectId(challengeDomain, transfer.SnapshotId),
+		SnapshotID:  transfer.SnapshotId,
+		Challenger:  transfer.CounterUserId,
+		Custodian:   custodian,
+		AppID:       node.AppID.String,
+		Message:     hex.EncodeToString(nonce),
+		State:       ChallengeStatePending,
+		Deadline:    t.Add(ChallengeTimeout),
+		CreatedAt:   t,
+		UpdatedAt:   t,
+	}
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		old, err := findChallenge(ctx, tx, challenge.ChallengeID)
+		if err != nil || old != nil {
+			challenge = old
+			return err
+		}
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("challenges", challengesColumns), challenge.values()...)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return challenge, nil
+}
+
+func UpdateChallengeSent(ctx context.Context, id string) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE challenges SET state=?,updated_at=? WHERE challenge_id=? AND state=?",
+			ChallengeStateSent, time.Now(), id, ChallengeStatePending)
+		return err
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+// AnswerChallenge accepts the custodian signature of the challenge message
+// sent back by the node app before the deadline, and records it as evidence.
+func AnswerChallenge(ctx context.Context, id, appID, signature string) (*Challenge, error) {
+	var challenge *Challenge
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		c, err := findChallenge(ctx, tx, id)
+		if err != nil {
+			return err
+		} else if c == nil {
+			return session.NotFoundError(ctx)
+		} else if c.AppID != appID {
+			return session.ForbiddenError(ctx)
+		}
+		challenge = c
+		if c.State != ChallengeStatePending && c.State != ChallengeStateSent {
+			return session.InvalidStateTransitionError(ctx, c.State, ChallengeStateAnswered)
+		}
+		t := time.Now()
+		if t.After(c.Deadline) {
+			return session.InvalidStateTransitionError(ctx, c.State, ChallengeStateAnswered)
+		}
+		if !verifyCustodianSignature(c.Custodian, signature, ChallengeSigningMessage(c.ChallengeID, c.Message)) {
+			return session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+		}
+		c.State, c.Signature, c.UpdatedAt = ChallengeStateAnswered, signature, t
+		_, err = tx.ExecContext(ctx, "UPDATE challenges SET state=?,signature=?,updated_at=? WHERE challenge_id=?", c.State, c.Signature, c.UpdatedAt, c.ChallengeID)
+		if err != nil {
+			return err
+		}
+		return writeEvidence(ctx, tx, c.evidence(EvidenceTypeChallengeAnswered), false)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return challenge, nil
+}
+
+// ExpireChallenges closes the unanswered challenges past deadline. A sent one
+// is expired with a slashable evidence, while one that never reached the node
+// app is marked undelivered without any evidence.
+func ExpireChallenges(ctx context.Context, now time.Time) ([]*Challenge, error) {
+	var expired []*Challenge
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM challenges WHERE state IN (?,?) ORDER BY created_at", strings.Join(challengesColumns, ","))
+		challenges, err := readChallengesWithTx(ctx, tx, query, ChallengeStatePending, ChallengeStateSent)
+		if err != nil {
+			return err
+		}
+		for _, c := range challenges {
+			if !c.Deadline.Before(now) {
+				continue
+			}
+			sent := c.State == ChallengeStateSent
+			c.State, c.UpdatedAt = ChallengeStateUndelivered, now
+			if sent {
+				c.State = ChallengeStateExpired
+			}
+			_, err = tx.ExecContext(ctx, "UPDATE challenges SET state=?,updated_at=? WHERE challenge_id=?", c.State, c.UpdatedAt, c.ChallengeID)
+			if err != nil {
+				return err
+			}
+			if sent {
+				err = writeEvidence(ctx, tx, c.evidence(EvidenceTypeChallengeMissed), true)
+				if err != nil {
+					return err
+				}
+			}
+			expired = append(expired, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return expired, nil
+}
+
+func ReadChallengesByState(ctx context.Context, state string, limit int) ([]*Challenge, error) {
+	query := fmt.Sprintf("SELECT %s FROM challenges WHERE state=? ORDER BY created_at LIMIT %d", strings.Join(challengesColumns, ","), limit)
+	return readChallenges(ctx, query, state)
+}
+
+func ReadChallenges(ctx context.Context, custodian string) ([]*Challenge, error) {
+	if custodian == "" {
+		query := fmt.Sprintf("SELECT %s FROM challenges ORDER BY created_at DESC LIMIT 500", strings.Join(challengesColumns, ","))
+		return readChallenges(ctx, query)
+	}
+	query := fmt.Sprintf("SELECT %s FROM challenges WHERE custodian=? ORDER BY created_at DESC LIMIT 500", strings.Join(challengesColumns, ","))
+	return readChallenges(ctx, query, custodian)
+}
+
+func readChallenges(ctx context.Context, query string, args ...any) ([]*Challenge, error) {
+	var challenges []*Challenge
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		challenges, err = readChallengesWithTx(ctx, tx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return challenges, nil
+}
+
+func readChallengesWithTx(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*Challenge, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var challenges []*Challenge
+	for rows.Next() {
+		c, err := challengeFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, c)
+	}
+	return challenges, rows.Err()
+}
+
+func (c *Challenge) evidence(typ string) *Evidence {
+	return &Evidence{
+		EvidenceID: bot.UniqueObjectId(challengeDomain, c.ChallengeID, typ),
+		Type:       typ,
+		Custodian:  c.Custodian,
+		Reporter:   c.Challenger,
+		Payload:    c.ChallengeID,
+		Signature:  c.Signature,
+		Verified:   true,
+		CreatedAt:  c.UpdatedAt,
+	}
+}
+
+func findChallenge(ctx context.Context, tx *sql.Tx, id string) (*Challenge, error) {
+	query := fmt.Sprintf("SELECT %s FROM challenges WHERE challenge_id=?", strings.Join(challengesColumns, ","))
+	return challengeFromRow(tx.QueryRowContext(ctx, query, id))
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChallenge(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	key, custodian := testCustodianKey()
+	appID := "f857e241-9f04-4c55-b3ca-48bfda6675df"
+	_, err := CreateNode(ctx, custodian, "payee", "kernel", appID, "hash")
+	assert.Nil(err)
+
+	transfer := &bot.TransferView{
+		SnapshotId:    "0c6b5e9e-5d9a-4d6c-a6e9-4c1b5b55c0b1",
+		CounterUserId: "e9e5b807-fa8b-455a-8dfa-b189d28310ff",
+		Amount:        "1",
+		Memo:          ChallengeMemoPrefix + "unknown",
+	}
+	_, err = CreateChallenge(ctx, transfer)
+	serr, _ := err.(*session.Error)
+	assert.NotNil(serr)
+	assert.Equal(10007, serr.Code)
+
+	transfer.Memo = ChallengeMemoPrefix + custodian
+	challenge, err := CreateChallenge(ctx, transfer)
+	assert.Nil(err)
+	assert.Equal(ChallengeStatePending, challenge.State)
+	assert.Equal(appID, challenge.AppID)
+	same, err := CreateChallenge(ctx, transfer)
+	assert.Nil(err)
+	assert.Equal(challenge.Message, same.Message)
+
+	assert.Nil(UpdateChallengeSent(ctx, challenge.ChallengeID))
+	sent, err := ReadChallengesByState(ctx, ChallengeStateSent, 10)
+	assert.Nil(err)
+	assert.Len(sent, 1)
+
+	sig := key.Sign(ChallengeSigningMessage(challenge.ChallengeID, challenge.Message))
+	_, err = AnswerChallenge(ctx, challenge.ChallengeID, "other", sig.String())
+	assert.NotNil(err)
+	_, err = AnswerChallenge(ctx, challenge.ChallengeID, appID, "00"+sig.String()[2:])
+	assert.NotNil(err)
+	answered, err := AnswerChallenge(ctx, challenge.ChallengeID, appID, sig.String())
+	assert.Nil(err)
+	assert.Equal(ChallengeStateAnswered, answered.State)
+
+	transfer.SnapshotId = "9a9d8a8e-7cf7-4bd4-9b0c-3c4ab0e7d1a2"
+	missed, err := CreateChallenge(ctx, transfer)
+	assert.Nil(err)
+	assert.Nil(UpdateChallengeSent(ctx, missed.ChallengeID))
+	transfer.SnapshotId = "3f1d0c3e-2a7b-4f5e-8c1d-6b2e9f0a4c7d"
+	undelivered, err := CreateChallenge(ctx, transfer)
+	assert.Nil(err)
+	expired, err := ExpireChallenges(ctx, time.Now())
+	assert.Nil(err)
+	assert.Len(expired, 0)
+	expired, err = ExpireChallenges(ctx, time.Now().Add(ChallengeTimeout+time.Second))
+	assert.Nil(err)
+	assert.Len(expired, 2)
+	assert.Equal(missed.ChallengeID, expired[0].ChallengeID)
+	assert.Equal(ChallengeStateExpired, expired[0].State)
+	assert.Equal(undelivered.ChallengeID, expired[1].ChallengeID)
+	assert.Equal(ChallengeStateUndelivered, expired[1].State)
+
+	evidences, err := ReadEvidences(ctx, custodian)
+	assert.Nil(err)
+	assert.Len(evidences, 2)
+	assert.Equal(EvidenceTypeChallengeAnswered, evidences[0].Type)
+	assert.False(evidences[0].ProposalID.Valid)
+	assert.Equal(EvidenceTypeChallengeMissed, evidences[1].Type)
+	assert.True(evidences[1].ProposalID.Valid)
+
+	challenges, err := ReadChallenges(ctx, custodian)
+	assert.Nil(err)
+	assert.Len(challenges, 3)
+}
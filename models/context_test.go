@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/externals"
 	"github.com/MixinNetwork/safe/governance/session"
 	"github.com/MixinNetwork/safe/governance/store"
 )
@@ -18,12 +19,16 @@ func teardownTestContext(ctx context.Context) {
 }
 
 func setupTestContext() context.Context {
-	config.InitConfiguration("test")
+	err := config.InitConfiguration("../config/config.toml", "test")
+	if err != nil {
+		panic(err)
+	}
 
 	db, err := store.OpenDatabase()
 	if err != nil {
 		panic(err)
 	}
 
-	return session.WithDatabase(context.Background(), db)
+	ctx := session.WithDatabase(context.Background(), db)
+	return session.WithKernel(ctx, externals.NewFakeKernelClient(nil))
 }
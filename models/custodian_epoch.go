@@ -0,0 +1,535 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	CustodianEpochStateProposed  = "proposed"
+	CustodianEpochStateActive    = "active"
+	CustodianEpochStateRetired   = "retired"
+	CustodianEpochStateCancelled = "cancelled"
+	CustodianEpochStateExpired   = "expired"
+
+	CustodianEpochApprovalTimeout = 7 * 24 * time.Hour
+)
+
+type CustodianEpoch struct {
+	Epoch      int64
+	Threshold  int
+	GroupKey   string
+	CeremonyID string
+	State      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Members   []string
+	Approvals []*CustodianApproval
+}
+
+type CustodianApproval struct {
+	Epoch     int64
+	Approver  string
+	Signature string
+	CreatedAt time.Time
+}
+
+var custodianEpochsColumns = []string{"epoch", "threshold", "group_key", "ceremony_id", "state", "created_at", "updated_at"}
+var custodianEpochApprovalsColumns = []string{"epoch", "approver", "signature", "created_at"}
+
+func (e *CustodianEpoch) values() []any {
+	return []any{e.Epoch, e.Threshold, e.GroupKey, e.CeremonyID, e.State, e.CreatedAt, e.UpdatedAt}
+}
+
+func (a *CustodianApproval) values() []any {
+	return []any{a.Epoch, a.Approver, a.Signature, a.CreatedAt}
+}
+
+func custodianEpochFromRow(row store.Row) (*CustodianEpoch, error) {
+	var e CustodianEpoch
+	err := row.Scan(&e.Epoch, &e.Threshold, &e.GroupKey, &e.CeremonyID, &e.State, &e.CreatedAt, &e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &e, err
+}
+
+func custodianApprovalFromRow(row store.Row) (*CustodianApproval, error) {
+	var a CustodianApproval
+	err := row.Scan(&a.Epoch, &a.Approver, &a.Signature, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &a, err
+}
+
+// ProposeCustodianEpoch proposes the next custodian membership, i.e. the
+// active members with the adds and removes applied, which must be exactly the
+// participants of the completed FROST ceremony that produced its group key.
+// The first epoch has no members to approve it and is active immediately.
+func ProposeCustodianEpoch(ctx context.Context, ceremonyID string, adds, removes []string) (*CustodianEpoch, error) {
+	var epoch *CustodianEpoch
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := expireCustodianEpochs(ctx, tx, time.Now())
+		if err != nil {
+			return err
+		}
+		var proposed int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM custodian_epochs WHERE state=?", CustodianEpochStateProposed).Scan(&proposed)
+		if err != nil {
+			return err
+		} else if proposed > 0 {
+			return session.InvalidStateTransitionError(ctx, CustodianEpochStateProposed, CustodianEpochStateProposed)
+		}
+		var used int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM custodian_epochs WHERE ceremony_id=? AND state IN (?,?,?)", ceremonyID,
+			CustodianEpochStateProposed, CustodianEpochStateActive, CustodianEpochStateRetired).Scan(&used)
+		if err != nil {
+			return err
+		} else if used > 0 {
+			return session.BadDataErrorWithFieldAndData(ctx, "ceremony_id", "used", ceremonyID)
+		}
+
+		c, err := findCeremony(ctx, tx, ceremonyID)
+		if err != nil {
+			return err
+		} else if c == nil || c.State != CeremonyStateCompleted {
+			return session.BadDataErrorWithFieldAndData(ctx, "ceremony_id", "incomplete", ceremonyID)
+		}
+		err = c.loadMembers(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		current, err := findActiveCustodianEpoch(ctx, tx)
+		if err != nil {
+			return err
+		}
+		members := make(map[string]bool)
+		if current != nil {
+			for _, m := range current.Members {
+				members[m] = true
+			}
+		}
+		for _, r := range removes {
+			if !members[r] {
+				return session.BadDataErrorWithFieldAndData(ctx, "removes", "invalid", r)
+			}
+			delete(members, r)
+		}
+		for _, a := range adds {
+			if members[a] {
+				return session.BadDataErrorWithFieldAndData(ctx, "adds", "invalid", a)
+			}
+			active, err := isActiveCustodian(ctx, tx, a)
+			if err != nil {
+				return err
+			} else if !active {
+				return session.BadDataErrorWithFieldAndData(ctx, "adds", "inactive", a)
+			}
+			members[a] = true
+		}
+		if len(members) != len(c.Members) {
+			return session.BadDataErrorWithFieldAndData(ctx, "ceremony_id", "members", ceremonyID)
+		}
+		for _, m := range c.Members {
+			if !members[m.Custodian] {
+				return session.BadDataErrorWithFieldAndData(ctx, "ceremony_id", "members", ceremonyID)
+			}
+		}
+
+		t := time.Now()
+		epoch = &CustodianEpoch{
+			Epoch:      1,
+			Threshold:  c.Threshold,
+			GroupKey:   c.GroupKey,
+			CeremonyID: c.CeremonyID,
+			State:      CustodianEpochStateActive,
+			CreatedAt:  t,
+			UpdatedAt:  t,
+		}
+		if current != nil {
+			err = tx.QueryRowContext(ctx, "SELECT MAX(epoch) FROM custodian_epochs").Scan(&epoch.Epoch)
+			if err != nil {
+				return err
+			}
+			epoch.Epoch = epoch.Epoch + 1
+			epoch.State = CustodianEpochStateProposed
+		}
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("custodian_epochs", custodianEpochsColumns), epoch.values()...)
+		if err != nil {
+			return err
+		}
+		for m := range members {
+			epoch.Members = append(epoch.Members, m)
+		}
+		sort.Strings(epoch.Members)
+		for _, m := range epoch.Members {
+			_, err = tx.ExecContext(ctx, "INSERT INTO custodian_epoch_members (epoch,custodian) VALUES (?,?)", epoch.Epoch, m)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return epoch, nil
+}
+
+// ApproveCustodianEpoch records the signature of a member of the active epoch
+// on the update payload of the proposed epoch, which replaces the active one
+// once the approvals reach the threshold of the active epoch before the
+// deadline of the proposal.
+func ApproveCustodianEpoch(ctx context.Context, number int64, approver, signature string) (*CustodianEpoch, error) {
+	var epoch *CustodianEpoch
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		e, err := findCustodianEpoch(ctx, tx, number)
+		if err != nil {
+			return err
+		} else if e == nil {
+			return session.NotFoundError(ctx)
+		}
+		epoch = e
+		if e.State != CustodianEpochStateProposed || time.Now().After(e.Deadline()) {
+			return session.InvalidStateTransitionError(ctx, e.State, CustodianEpochStateActive)
+		}
+		previous, err := findPreviousCustodianEpoch(ctx, tx, e.Epoch)
+		if err != nil {
+			return err
+		}
+		if previous == nil || !previous.member(approver) {
+			return session.ForbiddenError(ctx)
+		}
+		update, err := buildCustodianUpdate(e, previous)
+		if err != nil {
+			return err
+		}
+		if !verifyCustodianSignature(approver, signature, update.SigningMessage()) {
+			return session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+		}
+
+		t := time.Now()
+		a := &CustodianApproval{Epoch: e.Epoch, Approver: approver, Signature: signature, CreatedAt: t}
+		query := store.BuildInsertionSQL("custodian_epoch_approvals", custodianEpochApprovalsColumns) + " ON CONFLICT (epoch, approver) DO NOTHING"
+		_, err = tx.ExecContext(ctx, query, a.values()...)
+		if err != nil {
+			return err
+		}
+		err = e.loadApprovals(ctx, tx)
+		if err != nil || len(e.Approvals) < previous.Threshold {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE custodian_epochs SET state=?,updated_at=? WHERE epoch=?", CustodianEpochStateRetired, t, previous.Epoch)
+		if err != nil {
+			return err
+		}
+		e.State, e.UpdatedAt = CustodianEpochStateActive, t
+		_, err = tx.ExecContext(ctx, "UPDATE custodian_epochs SET state=?,updated_at=? WHERE epoch=?", e.State, e.UpdatedAt, e.Epoch)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return epoch, nil
+}
+
+// CancelCustodianEpoch withdraws the proposed epoch, so that another one can
+// be proposed with the next epoch number.
+func CancelCustodianEpoch(ctx context.Context, number int64) (*CustodianEpoch, error) {
+	var epoch *CustodianEpoch
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		e, err := findCustodianEpoch(ctx, tx, number)
+		if err != nil {
+			return err
+		} else if e == nil {
+			return session.NotFoundError(ctx)
+		}
+		epoch = e
+		if e.State != CustodianEpochStateProposed {
+			return session.InvalidStateTransitionError(ctx, e.State, CustodianEpochStateCancelled)
+		}
+		e.State, e.UpdatedAt = CustodianEpochStateCancelled, time.Now()
+		_, err = tx.ExecContext(ctx, "UPDATE custodian_epochs SET state=?,updated_at=? WHERE epoch=?", e.State, e.UpdatedAt, e.Epoch)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return epoch, nil
+}
+
+// ExpireCustodianEpochs ends the proposed epochs not approved before the
+// deadline, so that the rotation is not blocked by them.
+func ExpireCustodianEpochs(ctx context.Context, now time.Time) ([]*CustodianEpoch, error) {
+	var epochs []*CustodianEpoch
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		expired, err := expireCustodianEpochs(ctx, tx, now)
+		epochs = expired
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return epochs, nil
+}
+
+// BuildCustodianUpdate returns the update payload of the epoch, with all the
+// collected approval signatures indexed by the previous members order.
+func BuildCustodianUpdate(ctx context.Context, number int64) (*extra.CustodianUpdate, error) {
+	var update *extra.CustodianUpdate
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		e, err := findCustodianEpoch(ctx, tx, number)
+		if err != nil {
+			return err
+		} else if e == nil {
+			return session.NotFoundError(ctx)
+		}
+		previous, err := findPreviousCustodianEpoch(ctx, tx, e.Epoch)
+		if err != nil {
+			return err
+		} else if previous == nil {
+			return session.BadDataErrorWithFieldAndData(ctx, "epoch", "genesis", fmt.Sprint(number))
+		}
+		update, err = buildCustodianUpdate(e, previous)
+		if err != nil {
+			return err
+		}
+		members, err := custodianAddresses(previous.Members)
+		if err != nil {
+			return err
+		}
+		members = extra.SortCustodianMembers(members)
+		update.Signatures = make(map[uint16]*crypto.Signature)
+		for _, a := range e.Approvals {
+			buf, err := hex.DecodeString(a.Signature)
+			if err != nil {
+				return err
+			}
+			var sig crypto.Signature
+			copy(sig[:], buf)
+			for i, m := range members {
+				if m.String() == a.Approver {
+					update.Signatures[uint16(i)] = &sig
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return update, nil
+}
+
+func ReadCustodianEpoch(ctx context.Context, number int64) (*CustodianEpoch, error) {
+	var epoch *CustodianEpoch
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		e, err := findCustodianEpoch(ctx, tx, number)
+		epoch = e
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return epoch, nil
+}
+
+func ReadCustodianEpochs(ctx context.Context) ([]*CustodianEpoch, error) {
+	var epochs []*CustodianEpoch
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM custodian_epochs ORDER BY epoch DESC LIMIT 100", strings.Join(custodianEpochsColumns, ","))
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			e, err := custodianEpochFromRow(rows)
+			if err != nil {
+				return err
+			}
+			epochs = append(epochs, e)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, e := range epochs {
+			err = e.load(ctx, tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return epochs, nil
+}
+
+func expireCustodianEpochs(ctx context.Context, tx *sql.Tx, now time.Time) ([]*CustodianEpoch, error) {
+	query := fmt.Sprintf("SELECT %s FROM custodian_epochs WHERE state=?", strings.Join(custodianEpochsColumns, ","))
+	rows, err := tx.QueryContext(ctx, query, CustodianEpochStateProposed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var expired []*CustodianEpoch
+	for rows.Next() {
+		e, err := custodianEpochFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if now.After(e.Deadline()) {
+			expired = append(expired, e)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, e := range expired {
+		e.State, e.UpdatedAt = CustodianEpochStateExpired, now
+		_, err = tx.ExecContext(ctx, "UPDATE custodian_epochs SET state=?,updated_at=? WHERE epoch=? AND state=?", e.State, e.UpdatedAt, e.Epoch, CustodianEpochStateProposed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+func buildCustodianUpdate(e, previous *CustodianEpoch) (*extra.CustodianUpdate, error) {
+	members, err := custodianAddresses(e.Members)
+	if err != nil {
+		return nil, err
+	}
+	groupKey, err := crypto.KeyFromString(e.GroupKey)
+	if err != nil {
+		return nil, err
+	}
+	previousKey, err := crypto.KeyFromString(previous.GroupKey)
+	if err != nil {
+		return nil, err
+	}
+	return &extra.CustodianUpdate{
+		NetworkID:   networkID(),
+		Epoch:       uint64(e.Epoch),
+		PreviousKey: previousKey,
+		GroupKey:    groupKey,
+		Threshold:   uint16(e.Threshold),
+		Members:     members,
+	}, nil
+}
+
+func custodianAddresses(custodians []string) ([]common.Address, error) {
+	addrs := make([]common.Address, len(custodians))
+	for i, c := range custodians {
+		addr, err := common.NewAddressFromString(c)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = addr
+	}
+	return addrs, nil
+}
+
+func isActiveCustodian(ctx context.Context, tx *sql.Tx, custodian string) (bool, error) {
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM nodes WHERE custodian=? AND state IN ('%s')", strings.Join(activeNodeStates, "','"))
+	err := tx.QueryRowContext(ctx, query, custodian).Scan(&count)
+	return count > 0, err
+}
+
+func (e *CustodianEpoch) Deadline() time.Time {
+	return e.CreatedAt.Add(CustodianEpochApprovalTimeout)
+}
+
+func (e *CustodianEpoch) member(custodian string) bool {
+	for _, m := range e.Members {
+		if m == custodian {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *CustodianEpoch) load(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT custodian FROM custodian_epoch_members WHERE epoch=? ORDER BY custodian", e.Epoch)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	e.Members = nil
+	for rows.Next() {
+		var m string
+		err = rows.Scan(&m)
+		if err != nil {
+			return err
+		}
+		e.Members = append(e.Members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return e.loadApprovals(ctx, tx)
+}
+
+func (e *CustodianEpoch) loadApprovals(ctx context.Context, tx *sql.Tx) error {
+	query := fmt.Sprintf("SELECT %s FROM custodian_epoch_approvals WHERE epoch=? ORDER BY created_at", strings.Join(custodianEpochApprovalsColumns, ","))
+	rows, err := tx.QueryContext(ctx, query, e.Epoch)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	e.Approvals = nil
+	for rows.Next() {
+		a, err := custodianApprovalFromRow(rows)
+		if err != nil {
+			return err
+		}
+		e.Approvals = append(e.Approvals, a)
+	}
+	return rows.Err()
+}
+
+func findActiveCustodianEpoch(ctx context.Context, tx *sql.Tx) (*CustodianEpoch, error) {
+	query := fmt.Sprintf("SELECT %s FROM custodian_epochs WHERE state=? ORDER BY epoch DESC LIMIT 1", strings.Join(custodianEpochsColumns, ","))
+	e, err := custodianEpochFromRow(tx.QueryRowContext(ctx, query, CustodianEpochStateActive))
+	if err != nil || e == nil {
+		return e, err
+	}
+	return e, e.load(ctx, tx)
+}
+
+func findCustodianEpoch(ctx context.Context, tx *sql.Tx, number int64) (*CustodianEpoch, error) {
+	query := fmt.Sprintf("SELECT %s FROM custodian_epochs WHERE epoch=?", strings.Join(custodianEpochsColumns, ","))
+	e, err := custodianEpochFromRow(tx.QueryRowContext(ctx, query, number))
+	if err != nil || e == nil {
+		return e, err
+	}
+	return e, e.load(ctx, tx)
+}
+
+// findPreviousCustodianEpoch returns the epoch replaced by the epoch number,
+// skipping the cancelled and expired proposals in between.
+func findPreviousCustodianEpoch(ctx context.Context, tx *sql.Tx, number int64) (*CustodianEpoch, error) {
+	query := fmt.Sprintf("SELECT %s FROM custodian_epochs WHERE epoch<? AND state IN (?,?) ORDER BY epoch DESC LIMIT 1", strings.Join(custodianEpochsColumns, ","))
+	e, err := custodianEpochFromRow(tx.QueryRowContext(ctx, query, number, CustodianEpochStateActive, CustodianEpochStateRetired))
+	if err != nil || e == nil {
+		return e, err
+	}
+	return e, e.load(ctx, tx)
+}
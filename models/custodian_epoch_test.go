@@ -0,0 +1,196 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustodianEpoch(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	var custodians []string
+	keys := make(map[string]crypto.Key)
+	for i := 0; i < 4; i++ {
+		key, custodian := testCustodianKey()
+		keys[custodian] = key
+		custodians = append(custodians, custodian)
+		_, err := CreateNode(ctx, custodian, custodian, custodian, fmt.Sprintf("app-%s", custodian), custodian)
+		assert.Nil(err)
+	}
+
+	genesis, _ := testCompletedCeremony(ctx, 2, custodians[:3])
+	_, err := ProposeCustodianEpoch(ctx, genesis, custodians[:2], nil)
+	assert.NotNil(err)
+	epoch, err := ProposeCustodianEpoch(ctx, genesis, custodians[:3], nil)
+	assert.Nil(err)
+	assert.Equal(int64(1), epoch.Epoch)
+	assert.Equal(CustodianEpochStateActive, epoch.State)
+	_, err = BuildCustodianUpdate(ctx, 1)
+	assert.NotNil(err)
+
+	next, _ := testCompletedCeremony(ctx, 2, custodians[1:])
+	_, err = ProposeCustodianEpoch(ctx, next, custodians[3:], nil)
+	assert.NotNil(err)
+	epoch, err = ProposeCustodianEpoch(ctx, next, custodians[3:], custodians[:1])
+	assert.Nil(err)
+	assert.Equal(int64(2), epoch.Epoch)
+	assert.Equal(CustodianEpochStateProposed, epoch.State)
+	other, _ := testCompletedCeremony(ctx, 2, custodians[1:])
+	_, err = ProposeCustodianEpoch(ctx, other, nil, nil)
+	assert.NotNil(err)
+
+	update, err := BuildCustodianUpdate(ctx, 2)
+	assert.Nil(err)
+	assert.Len(update.Members, 3)
+	assert.Equal(uint16(2), update.Threshold)
+	msg := update.SigningMessage()
+
+	newcomer := custodians[3]
+	key := keys[newcomer]
+	sig := key.Sign(msg)
+	_, err = ApproveCustodianEpoch(ctx, 2, newcomer, sig.String())
+	assert.NotNil(err)
+	key = keys[custodians[0]]
+	sig = key.Sign(msg)
+	_, err = ApproveCustodianEpoch(ctx, 2, custodians[0], "00"+sig.String()[2:])
+	assert.NotNil(err)
+	epoch, err = ApproveCustodianEpoch(ctx, 2, custodians[0], sig.String())
+	assert.Nil(err)
+	assert.Equal(CustodianEpochStateProposed, epoch.State)
+	epoch, err = ApproveCustodianEpoch(ctx, 2, custodians[0], sig.String())
+	assert.Nil(err)
+	assert.Len(epoch.Approvals, 1)
+	key = keys[custodians[1]]
+	sig = key.Sign(msg)
+	epoch, err = ApproveCustodianEpoch(ctx, 2, custodians[1], sig.String())
+	assert.Nil(err)
+	assert.Equal(CustodianEpochStateActive, epoch.State)
+	assert.Len(epoch.Approvals, 2)
+
+	previous, err := ReadCustodianEpoch(ctx, 1)
+	assert.Nil(err)
+	assert.Equal(CustodianEpochStateRetired, previous.State)
+	epochs, err := ReadCustodianEpochs(ctx)
+	assert.Nil(err)
+	assert.Len(epochs, 2)
+
+	update, err = BuildCustodianUpdate(ctx, 2)
+	assert.Nil(err)
+	assert.Len(update.Signatures, 2)
+	assert.Len(update.Encode(), len(update.Payload())+2+2*(2+64))
+}
+
+func TestCustodianEpochCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	var custodians []string
+	keys := make(map[string]crypto.Key)
+	for i := 0; i < 4; i++ {
+		key, custodian := testCustodianKey()
+		keys[custodian] = key
+		custodians = append(custodians, custodian)
+		_, err := CreateNode(ctx, custodian, custodian, custodian, fmt.Sprintf("app-%s", custodian), custodian)
+		assert.Nil(err)
+	}
+	genesis, genesisKey := testCompletedCeremony(ctx, 2, custodians[:3])
+	_, err := ProposeCustodianEpoch(ctx, genesis, custodians[:3], nil)
+	assert.Nil(err)
+
+	next, _ := testCompletedCeremony(ctx, 2, custodians)
+	epoch, err := ProposeCustodianEpoch(ctx, next, custodians[3:], nil)
+	assert.Nil(err)
+	assert.Equal(int64(2), epoch.Epoch)
+	_, err = CancelCustodianEpoch(ctx, 1)
+	assert.NotNil(err)
+	epoch, err = CancelCustodianEpoch(ctx, 2)
+	assert.Nil(err)
+	assert.Equal(CustodianEpochStateCancelled, epoch.State)
+	_, err = CancelCustodianEpoch(ctx, 2)
+	assert.NotNil(err)
+
+	epoch, err = ProposeCustodianEpoch(ctx, next, custodians[3:], nil)
+	assert.Nil(err)
+	assert.Equal(int64(3), epoch.Epoch)
+	update, err := BuildCustodianUpdate(ctx, 3)
+	assert.Nil(err)
+	assert.Equal(uint64(3), update.Epoch)
+	assert.Equal(genesisKey.Public(), update.PreviousKey)
+
+	expired, err := ExpireCustodianEpochs(ctx, time.Now())
+	assert.Nil(err)
+	assert.Len(expired, 0)
+	expired, err = ExpireCustodianEpochs(ctx, time.Now().Add(CustodianEpochApprovalTimeout+time.Second))
+	assert.Nil(err)
+	assert.Len(expired, 1)
+	assert.Equal(CustodianEpochStateExpired, expired[0].State)
+	key := keys[custodians[0]]
+	sig := key.Sign(update.SigningMessage())
+	_, err = ApproveCustodianEpoch(ctx, 3, custodians[0], sig.String())
+	assert.NotNil(err)
+
+	other, _ := testCompletedCeremony(ctx, 2, custodians)
+	epoch, err = ProposeCustodianEpoch(ctx, other, custodians[3:], nil)
+	assert.Nil(err)
+	assert.Equal(int64(4), epoch.Epoch)
+	update, err = BuildCustodianUpdate(ctx, 4)
+	assert.Nil(err)
+	for _, c := range custodians[:2] {
+		key := keys[c]
+		sig := key.Sign(update.SigningMessage())
+		epoch, err = ApproveCustodianEpoch(ctx, 4, c, sig.String())
+		assert.Nil(err)
+	}
+	assert.Equal(CustodianEpochStateActive, epoch.State)
+	previous, err := ReadCustodianEpoch(ctx, 1)
+	assert.Nil(err)
+	assert.Equal(CustodianEpochStateRetired, previous.State)
+}
+
+func testCompletedCeremony(ctx context.Context, threshold int, custodians []string) (string, crypto.Key) {
+	id := uuid.Must(uuid.NewV4()).String()
+	t := time.Now()
+	key, _ := testCustodianKey()
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		c := &Ceremony{
+			CeremonyID:   id,
+			Threshold:    threshold,
+			Participants: len(custodians),
+			Round:        CeremonyRoundResults,
+			State:        CeremonyStateCompleted,
+			GroupKey:     key.Public().String(),
+			CreatedAt:    t,
+			UpdatedAt:    t,
+		}
+		_, err := tx.ExecContext(ctx, store.BuildInsertionSQL("ceremonies", ceremoniesColumns), c.values()...)
+		if err != nil {
+			return err
+		}
+		for i, custodian := range custodians {
+			p := &CeremonyParticipant{CeremonyID: id, Custodian: custodian, AppID: custodian, Position: i + 1, UpdatedAt: t}
+			_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("ceremony_participants", ceremonyParticipantsColumns), p.values()...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return id, key
+}
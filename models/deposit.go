@@ -0,0 +1,288 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	DepositStatePending         = "pending"
+	DepositStateDomainSigned    = "domain_signed"
+	DepositStateCustodianSigned = "custodian_signed"
+	DepositStateFinalized       = "finalized"
+
+	DepositSourceRPC    = "rpc"
+	DepositSourceDomain = "domain"
+
+	DepositSignerDomain    = "domain"
+	DepositSignerCustodian = "custodian"
+)
+
+// Deposit is a kernel deposit transaction guarded by the Domain and the
+// custodian key, both sign the transaction payload before it is final.
+type Deposit struct {
+	TransactionHash    string
+	Payload            string
+	Chain              string
+	AssetKey           string
+	DepositHash        string
+	OutputIndex        uint64
+	Amount             string
+	Source             string
+	State              string
+	DomainSignature    string
+	CustodianSignature string
+	CustodianEpoch     int64
+	SnapshotHash       string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+var depositsColumns = []string{"transaction_hash", "payload", "chain", "asset_key", "deposit_hash", "output_index", "amount", "source", "state", "domain_signature", "custodian_signature", "custodian_epoch", "snapshot_hash", "created_at", "updated_at"}
+
+func (d *Deposit) values() []any {
+	return []any{d.TransactionHash, d.Payload, d.Chain, d.AssetKey, d.DepositHash, d.OutputIndex, d.Amount, d.Source, d.State, d.DomainSignature, d.CustodianSignature, d.CustodianEpoch, d.SnapshotHash, d.CreatedAt, d.UpdatedAt}
+}
+
+func depositFromRow(row store.Row) (*Deposit, error) {
+	var d Deposit
+	err := row.Scan(&d.TransactionHash, &d.Payload, &d.Chain, &d.AssetKey, &d.DepositHash, &d.OutputIndex, &d.Amount, &d.Source, &d.State, &d.DomainSignature, &d.CustodianSignature, &d.CustodianEpoch, &d.SnapshotHash, &d.CreatedAt, &d.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &d, err
+}
+
+// IngestDeposit queues the hex encoded kernel deposit transaction submitted by
+// the Domain, an already queued transaction is returned as is.
+func IngestDeposit(ctx context.Context, raw string) (*Deposit, error) {
+	return ingestDeposit(ctx, raw, DepositSourceDomain)
+}
+
+// IngestKernelDeposit queues the deposit transaction read from the kernel RPC,
+// the kernel returns both pending and finalized transactions.
+func IngestKernelDeposit(ctx context.Context, hash string) (*Deposit, error) {
+	tx, err := session.Kernel(ctx).ReadTransaction(hash)
+	if err != nil {
+		return nil, session.ServerError(ctx, err)
+	} else if tx == nil || tx.Hex == "" {
+		return nil, session.NotFoundError(ctx)
+	}
+	return ingestDeposit(ctx, tx.Hex, DepositSourceRPC)
+}
+
+func ingestDeposit(ctx context.Context, raw, source string) (*Deposit, error) {
+	buf, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "raw", "invalid", raw)
+	}
+	ver, err := common.UnmarshalVersionedTransaction(buf)
+	if err != nil {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "raw", "invalid", raw)
+	}
+	data := ver.DepositData()
+	if data == nil {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "raw", "deposit", raw)
+	}
+
+	var deposit *Deposit
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		hash := ver.PayloadHash().String()
+		d, err := findDeposit(ctx, tx, hash)
+		if err != nil || d != nil {
+			deposit = d
+			return err
+		}
+		t := time.Now()
+		deposit = &Deposit{
+			TransactionHash: hash,
+			Payload:         hex.EncodeToString(ver.PayloadMarshal()),
+			Chain:           data.Chain.String(),
+			AssetKey:        data.AssetKey,
+			DepositHash:     data.TransactionHash,
+			OutputIndex:     data.OutputIndex,
+			Amount:          data.Amount.String(),
+			Source:          source,
+			State:           DepositStatePending,
+			CreatedAt:       t,
+			UpdatedAt:       t,
+		}
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("deposits", depositsColumns), deposit.values()...)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return deposit, nil
+}
+
+// SignDeposit accepts the Domain signature of a pending deposit, or the
+// custodian signature of a Domain signed one. The Domain signature must match
+// the configured domain key, and the custodian signature the group key of the
+// active custodian epoch.
+func SignDeposit(ctx context.Context, hash, signer, signature string) (*Deposit, error) {
+	buf, err := hex.DecodeString(signature)
+	if err != nil || len(buf) != len(crypto.Signature{}) {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+	}
+	var sig crypto.Signature
+	copy(sig[:], buf)
+
+	var deposit *Deposit
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		d, err := findDeposit(ctx, tx, hash)
+		if err != nil {
+			return err
+		} else if d == nil {
+			return session.NotFoundError(ctx)
+		}
+		deposit = d
+		msg, err := hex.DecodeString(d.Payload)
+		if err != nil {
+			return err
+		}
+
+		t := time.Now()
+		switch signer {
+		case DepositSignerDomain:
+			if d.State != DepositStatePending {
+				return session.InvalidStateTransitionError(ctx, d.State, DepositStateDomainSigned)
+			}
+			key, err := crypto.KeyFromString(config.AppConfig.Governance.DomainKey)
+			if err != nil || !key.Verify(msg, sig) {
+				return session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+			}
+			d.State, d.DomainSignature, d.UpdatedAt = DepositStateDomainSigned, signature, t
+		case DepositSignerCustodian:
+			if d.State != DepositStateDomainSigned {
+				return session.InvalidStateTransitionError(ctx, d.State, DepositStateCustodianSigned)
+			}
+			epoch, err := findActiveCustodianEpoch(ctx, tx)
+			if err != nil {
+				return err
+			} else if epoch == nil {
+				return session.InvalidStateTransitionError(ctx, d.State, DepositStateCustodianSigned)
+			}
+			key, err := crypto.KeyFromString(epoch.GroupKey)
+			if err != nil || !key.Verify(msg, sig) {
+				return session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+			}
+			d.State, d.CustodianSignature, d.CustodianEpoch, d.UpdatedAt = DepositStateCustodianSigned, signature, epoch.Epoch, t
+		default:
+			return session.BadDataErrorWithFieldAndData(ctx, "signer", "invalid", signer)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE deposits SET state=?,domain_signature=?,custodian_signature=?,custodian_epoch=?,updated_at=? WHERE transaction_hash=?",
+			d.State, d.DomainSignature, d.CustodianSignature, d.CustodianEpoch, d.UpdatedAt, d.TransactionHash)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return deposit, nil
+}
+
+// FinalizeDeposit marks the fully signed deposit finalized once the kernel
+// includes it in a snapshot, otherwise the deposit is returned unchanged.
+func FinalizeDeposit(ctx context.Context, d *Deposit) (*Deposit, error) {
+	tx, err := session.Kernel(ctx).ReadTransaction(d.TransactionHash)
+	if err != nil {
+		return nil, session.ServerError(ctx, err)
+	} else if tx == nil || tx.Snapshot == "" {
+		return d, nil
+	}
+	var deposit *Deposit
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, db *sql.Tx) error {
+		old, err := findDeposit(ctx, db, d.TransactionHash)
+		if err != nil || old == nil {
+			return err
+		}
+		deposit = old
+		if old.State != DepositStateCustodianSigned {
+			return nil
+		}
+		old.State, old.SnapshotHash, old.UpdatedAt = DepositStateFinalized, tx.Snapshot, time.Now()
+		_, err = db.ExecContext(ctx, "UPDATE deposits SET state=?,snapshot_hash=?,updated_at=? WHERE transaction_hash=? AND state=?",
+			old.State, old.SnapshotHash, old.UpdatedAt, old.TransactionHash, DepositStateCustodianSigned)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return deposit, nil
+}
+
+// ReadDepositsToFinalize pages through all the fully signed deposits by the
+// transaction hash, starting after the hash of the last page.
+func ReadDepositsToFinalize(ctx context.Context, after string, limit int) ([]*Deposit, error) {
+	query := fmt.Sprintf("SELECT %s FROM deposits WHERE state=? AND transaction_hash>? ORDER BY transaction_hash LIMIT %d", strings.Join(depositsColumns, ","), limit)
+	return readDeposits(ctx, query, DepositStateCustodianSigned, after)
+}
+
+// ReadUnsignedDeposits returns the deposits waiting for the signature of the
+// signer, i.e. pending ones for the Domain and Domain signed ones for the
+// custodian.
+func ReadUnsignedDeposits(ctx context.Context, signer string) ([]*Deposit, error) {
+	switch signer {
+	case DepositSignerDomain:
+		return ReadDepositsByState(ctx, DepositStatePending)
+	case DepositSignerCustodian:
+		return ReadDepositsByState(ctx, DepositStateDomainSigned)
+	}
+	return nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "invalid", signer)
+}
+
+func ReadDepositsByState(ctx context.Context, state string) ([]*Deposit, error) {
+	query := fmt.Sprintf("SELECT %s FROM deposits WHERE state=? ORDER BY created_at LIMIT 100", strings.Join(depositsColumns, ","))
+	return readDeposits(ctx, query, state)
+}
+
+func readDeposits(ctx context.Context, query string, args ...any) ([]*Deposit, error) {
+	var deposits []*Deposit
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			d, err := depositFromRow(rows)
+			if err != nil {
+				return err
+			}
+			deposits = append(deposits, d)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return deposits, nil
+}
+
+func ReadDeposit(ctx context.Context, hash string) (*Deposit, error) {
+	var deposit *Deposit
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		d, err := findDeposit(ctx, tx, hash)
+		deposit = d
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return deposit, nil
+}
+
+func findDeposit(ctx context.Context, tx *sql.Tx, hash string) (*Deposit, error) {
+	query := fmt.Sprintf("SELECT %s FROM deposits WHERE transaction_hash=?", strings.Join(depositsColumns, ","))
+	return depositFromRow(tx.QueryRowContext(ctx, query, hash))
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeposit(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	domain, _ := testCustodianKey()
+	config.AppConfig.Governance.DomainKey = domain.Public().String()
+
+	var custodians []string
+	for i := 0; i < 2; i++ {
+		_, custodian := testCustodianKey()
+		custodians = append(custodians, custodian)
+		_, err := CreateNode(ctx, custodian, custodian, custodian, fmt.Sprintf("app-%s", custodian), custodian)
+		assert.Nil(err)
+	}
+
+	raw, hash := testDepositTransaction("0xdeposit")
+	_, err := IngestDeposit(ctx, "00"+raw)
+	assert.NotNil(err)
+	deposit, err := IngestDeposit(ctx, raw)
+	assert.Nil(err)
+	assert.Equal(hash, deposit.TransactionHash)
+	assert.Equal(DepositStatePending, deposit.State)
+	assert.Equal(DepositSourceDomain, deposit.Source)
+	assert.Equal("0xdeposit", deposit.DepositHash)
+	deposit, err = IngestDeposit(ctx, raw)
+	assert.Nil(err)
+	assert.Equal(DepositStatePending, deposit.State)
+
+	kernel := session.Kernel(ctx).(*externals.FakeKernelClient)
+	other, otherHash := testDepositTransaction("0xother")
+	_, err = IngestKernelDeposit(ctx, otherHash)
+	assert.NotNil(err)
+	kernel.PutTransaction(&externals.Transaction{Hash: otherHash, Hex: other})
+	deposit, err = IngestKernelDeposit(ctx, otherHash)
+	assert.Nil(err)
+	assert.Equal(DepositSourceRPC, deposit.Source)
+
+	unsigned, err := ReadUnsignedDeposits(ctx, DepositSignerDomain)
+	assert.Nil(err)
+	assert.Len(unsigned, 2)
+	_, err = ReadUnsignedDeposits(ctx, "unknown")
+	assert.NotNil(err)
+
+	msg, _ := hex.DecodeString(deposit.Payload)
+	group, _ := testCustodianKey()
+	_, err = SignDeposit(ctx, hash, DepositSignerCustodian, group.Sign(msg).String())
+	assert.NotNil(err)
+	_, err = SignDeposit(ctx, hash, DepositSignerDomain, group.Sign(msg).String())
+	assert.NotNil(err)
+	deposit, err = SignDeposit(ctx, otherHash, DepositSignerDomain, domain.Sign(msg).String())
+	assert.Nil(err)
+	assert.Equal(DepositStateDomainSigned, deposit.State)
+	_, err = SignDeposit(ctx, otherHash, DepositSignerCustodian, group.Sign(msg).String())
+	assert.NotNil(err)
+
+	ceremony, group := testCompletedCeremony(ctx, 2, custodians)
+	_, err = ProposeCustodianEpoch(ctx, ceremony, custodians, nil)
+	assert.Nil(err)
+	unsigned, err = ReadUnsignedDeposits(ctx, DepositSignerCustodian)
+	assert.Nil(err)
+	assert.Len(unsigned, 1)
+	_, err = SignDeposit(ctx, otherHash, DepositSignerCustodian, domain.Sign(msg).String())
+	assert.NotNil(err)
+	deposit, err = SignDeposit(ctx, otherHash, DepositSignerCustodian, group.Sign(msg).String())
+	assert.Nil(err)
+	assert.Equal(DepositStateCustodianSigned, deposit.State)
+	assert.Equal(int64(1), deposit.CustodianEpoch)
+
+	deposits, err := ReadDepositsToFinalize(ctx, "", 10)
+	assert.Nil(err)
+	assert.Len(deposits, 1)
+	deposits, err = ReadDepositsToFinalize(ctx, otherHash, 10)
+	assert.Nil(err)
+	assert.Len(deposits, 0)
+	deposit, err = FinalizeDeposit(ctx, deposit)
+	assert.Nil(err)
+	assert.Equal(DepositStateCustodianSigned, deposit.State)
+	snapshot := crypto.NewHash([]byte(otherHash)).String()
+	kernel.PutTransaction(&externals.Transaction{Hash: otherHash, Hex: other, Snapshot: snapshot})
+	deposit, err = FinalizeDeposit(ctx, deposit)
+	assert.Nil(err)
+	assert.Equal(DepositStateFinalized, deposit.State)
+	deposit, err = ReadDeposit(ctx, otherHash)
+	assert.Nil(err)
+	assert.Equal(DepositStateFinalized, deposit.State)
+	assert.Equal(snapshot, deposit.SnapshotHash)
+}
+
+func testDepositTransaction(hash string) (string, string) {
+	chain := crypto.NewHash([]byte("chain"))
+	asset := (&common.Asset{ChainId: chain, AssetKey: "asset"}).AssetId()
+	tx := common.NewTransactionV4(asset)
+	tx.AddDepositInput(&common.DepositData{
+		Chain:           chain,
+		AssetKey:        "asset",
+		TransactionHash: hash,
+		Amount:          common.NewIntegerFromString("1"),
+	})
+	ver := tx.AsVersioned()
+	return hex.EncodeToString(ver.Marshal()), ver.PayloadHash().String()
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/externals"
+)
+
+// CheckNodesEligibility re-validates every paid Safe node against the kernel
+// node registry, and marks those whose kernel node has been removed for longer
+// than the grace period, or has another payee, as ineligible. Nodes missing in
+// the registry or in any other state are left to the next check.
+func CheckNodesEligibility(ctx context.Context) ([]*Node, error) {
+	var ineligible []*Node
+	for _, state := range []string{NodeStatePaid, NodeStateAppAssigned, NodeStateKeystoreDelivered, NodeStateMigrated} {
+		nodes, err := readAllNodesByState(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			gone, err := kernelNodeGone(ctx, n, time.Now())
+			if err != nil {
+				return nil, err
+			} else if !gone {
+				continue
+			}
+			node, err := UpdateNodeState(ctx, n.Custodian, NodeStateIneligible)
+			if err != nil {
+				return nil, err
+			}
+			ineligible = append(ineligible, node)
+		}
+	}
+	return ineligible, nil
+}
+
+func kernelNodeGone(ctx context.Context, n *Node, now time.Time) (bool, error) {
+	kn, err := ReadKernelNode(ctx, n.KernelID)
+	if err != nil || kn == nil {
+		return false, err
+	}
+	if kn.Payee != n.Payee {
+		return true, nil
+	}
+	if kn.State != KernelNodeStateRemoved {
+		return false, nil
+	}
+	events, err := ReadKernelNodeEvents(ctx, n.KernelID)
+	if err != nil {
+		return false, err
+	}
+	removed := kernelNodeRemovedAt(events)
+	return !removed.IsZero() && !removed.Add(externals.RemovedNodeGracePeriod).After(now), nil
+}
+
+// ReadNodeOperator returns the user who paid the registration fee of node,
+// which is empty when the payer was never recorded.
+func ReadNodeOperator(ctx context.Context, node *Node) (string, error) {
+	fee, err := ReadNodeFee(ctx, node.Custodian)
+	if err != nil || fee == nil {
+		return "", err
+	}
+	return fee.Payer, nil
+}
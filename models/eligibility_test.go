@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckNodesEligibility(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	id := "394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144"
+	payee := "XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4"
+	signer := "XIN4qtYcAuAsJFnHp61waUheVsiK1byouLqbhrA8VpSQwxHs4z8LPjpFRrx3zdmiXZuFSwJ8CAMCwLkxap1LbRWHk2iVsLyx"
+	hash := "5e7f37fd76bea1647d46c396e21c6496f3033f03ea50121500c6e6c2df5294b7"
+
+	node, err := CreateNode(ctx, "custodian", payee, id, "app", hash)
+	assert.Nil(err)
+	nodes, err := CheckNodesEligibility(ctx)
+	assert.Nil(err)
+	assert.Len(nodes, 0)
+
+	kernel := session.Kernel(ctx).(*externals.FakeKernelClient)
+	removed := time.Now().Add(-externals.RemovedNodeGracePeriod - time.Hour)
+	for _, k := range []string{"pledging", "recent", "missing", "moved"} {
+		_, err = CreateNode(ctx, k, k, k, k, k)
+		assert.Nil(err)
+	}
+	kernel.SetNodes([]*externals.Node{
+		{Id: id, Signer: signer, Payee: payee, State: KernelNodeStateRemoved, Timestamp: removed.UnixNano()},
+		{Id: "pledging", Signer: signer, Payee: "pledging", State: "PLEDGING"},
+		{Id: "recent", Signer: signer, Payee: "recent", State: KernelNodeStateRemoved, Timestamp: time.Now().UnixNano()},
+		{Id: "moved", Signer: signer, Payee: signer, State: KernelNodeStateAccepted},
+	})
+	_, err = SyncKernelNodes(ctx)
+	assert.Nil(err)
+	nodes, err = CheckNodesEligibility(ctx)
+	assert.Nil(err)
+	assert.Len(nodes, 2)
+	assert.Equal("custodian", nodes[0].Custodian)
+	assert.Equal(NodeStateIneligible, nodes[0].State)
+	assert.Equal("moved", nodes[1].Custodian)
+	assert.Equal(NodeStateIneligible, nodes[1].State)
+	nodes, err = CheckNodesEligibility(ctx)
+	assert.Nil(err)
+	assert.Len(nodes, 0)
+
+	operator, err := ReadNodeOperator(ctx, node)
+	assert.Nil(err)
+	assert.Equal("", operator)
+	err = testNodeFee(ctx, node.Custodian, &bot.TransferView{
+		SnapshotId:    "0c6b5e9e-5d9a-4d6c-a6e9-4c1b5b55c0b1",
+		CounterUserId: "e9e5b807-fa8b-455a-8dfa-b189d28310ff",
+		AssetId:       "c94ac88f-4671-3976-b60a-09064f1811e8",
+		Amount:        "100",
+		Memo:          hash,
+		CreatedAt:     time.Now(),
+	})
+	assert.Nil(err)
+	operator, err = ReadNodeOperator(ctx, node)
+	assert.Nil(err)
+	assert.Equal("e9e5b807-fa8b-455a-8dfa-b189d28310ff", operator)
+}
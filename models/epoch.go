@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+type RegistrationEpoch struct {
+	Epoch      *config.Epoch
+	Open       bool
+	Registered int
+}
+
+func ReadRegistrationEpoch(ctx context.Context) (*RegistrationEpoch, error) {
+	now := time.Now()
+	epoch, open := config.AppConfig.CurrentEpoch(now)
+	re := &RegistrationEpoch{Epoch: epoch, Open: open}
+	if !open {
+		re.Epoch = config.AppConfig.NextEpoch(now)
+	}
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		count, err := countEpochRegistrations(ctx, tx, re.Epoch)
+		re.Registered = count
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return re, nil
+}
+
+func checkRegistrationEpoch(ctx context.Context, tx *sql.Tx) error {
+	epoch, open := config.AppConfig.CurrentEpoch(time.Now())
+	if !open {
+		return session.RegistrationClosedError(ctx)
+	} else if epoch == nil {
+		return nil
+	}
+	count, err := countEpochRegistrations(ctx, tx, epoch)
+	if err != nil {
+		return err
+	} else if count >= epoch.Seats {
+		return session.NoSeatsLeftError(ctx)
+	}
+	return nil
+}
+
+// countEpochRegistrations counts the nodes paid in the epoch, which is
+// recorded on the node when the payment reserves its app seat.
+func countEpochRegistrations(ctx context.Context, tx *sql.Tx, epoch *config.Epoch) (int, error) {
+	var count int
+	if epoch == nil {
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes WHERE app_id IS NOT NULL").Scan(&count)
+		return count, err
+	}
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes WHERE app_id IS NOT NULL AND epoch=?", epoch.Name).Scan(&count)
+	return count, err
+}
+
+func currentEpochName(t time.Time) string {
+	epoch, _ := config.AppConfig.CurrentEpoch(t)
+	if epoch == nil {
+		return ""
+	}
+	return epoch.Name
+}
+
+// SyncNodeEpochs records the epoch of the nodes paid before epochs were kept
+// on the node, by the epoch open when the node was created.
+func SyncNodeEpochs(ctx context.Context) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT custodian,created_at FROM nodes WHERE app_id IS NOT NULL AND epoch=''")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		epochs := make(map[string]string)
+		for rows.Next() {
+			var custodian string
+			var t time.Time
+			err = rows.Scan(&custodian, &t)
+			if err != nil {
+				return err
+			}
+			if name := currentEpochName(t); name != "" {
+				epochs[custodian] = name
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for custodian, name := range epochs {
+			_, err = tx.ExecContext(ctx, "UPDATE nodes SET epoch=? WHERE custodian=?", name, custodian)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
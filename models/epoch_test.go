@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationEpoch(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	epoch, err := ReadRegistrationEpoch(ctx)
+	assert.Nil(err)
+	assert.True(epoch.Open)
+	assert.Nil(epoch.Epoch)
+
+	now := time.Now()
+	config.AppConfig.Governance.Epochs = []*config.Epoch{
+		{Name: "genesis", Open: now.Add(-48 * time.Hour), Close: now.Add(-24 * time.Hour), Seats: 50},
+		{Name: "second", Open: now.Add(24 * time.Hour), Close: now.Add(48 * time.Hour), Seats: 1},
+	}
+	assert.Nil(config.AppConfig.Validate())
+	epoch, err = ReadRegistrationEpoch(ctx)
+	assert.Nil(err)
+	assert.False(epoch.Open)
+	assert.Equal("second", epoch.Epoch.Name)
+	_, err = CreateNodeByExtra(ctx, buildTestExtra())
+	serr, ok := err.(*session.Error)
+	assert.True(ok)
+	assert.Equal(10006, serr.Code)
+
+	config.AppConfig.Governance.Epochs[1].Open = now.Add(-time.Hour)
+	_, err = CreateNode(ctx, "custodian", "payee", "kernel", "app", "hash")
+	assert.Nil(err)
+	epoch, err = ReadRegistrationEpoch(ctx)
+	assert.Nil(err)
+	assert.True(epoch.Open)
+	assert.Equal(1, epoch.Registered)
+	_, err = CreateNodeByExtra(ctx, buildTestExtra())
+	serr, ok = err.(*session.Error)
+	assert.True(ok)
+	assert.Equal(10005, serr.Code)
+
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE nodes SET epoch=''")
+		return err
+	})
+	assert.Nil(err)
+	epoch, err = ReadRegistrationEpoch(ctx)
+	assert.Nil(err)
+	assert.Equal(0, epoch.Registered)
+	assert.Nil(SyncNodeEpochs(ctx))
+	epoch, err = ReadRegistrationEpoch(ctx)
+	assert.Nil(err)
+	assert.Equal(1, epoch.Registered)
+
+	config.AppConfig.Governance.Epochs[1].Open = now.Add(-72 * time.Hour)
+	assert.NotNil(config.AppConfig.Validate())
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	KernelNodeStateAccepted = "ACCEPTED"
+	KernelNodeStateRemoved  = "REMOVED"
+)
+
+type KernelNode struct {
+	NodeID      string
+	Signer      string
+	Payee       string
+	State       string
+	Timestamp   int64
+	Transaction string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type KernelNodeEvent struct {
+	EventID   string
+	NodeID    string
+	Signer    string
+	Payee     string
+	State     string
+	Timestamp int64
+	CreatedAt time.Time
+}
+
+var kernelNodesColumns = []string{"node_id", "signer", "payee", "state", "timestamp", "transaction_hash", "created_at", "updated_at"}
+var kernelNodeEventsColumns = []string{"event_id", "node_id", "signer", "payee", "state", "timestamp", "created_at"}
+
+func (n *KernelNode) values() []any {
+	return []any{n.NodeID, n.Signer, n.Payee, n.State, n.Timestamp, n.Transaction, n.CreatedAt, n.UpdatedAt}
+}
+
+func (e *KernelNodeEvent) values() []any {
+	return []any{e.EventID, e.NodeID, e.Signer, e.Payee, e.State, e.Timestamp, e.CreatedAt}
+}
+
+func kernelNodeFromRow(row store.Row) (*KernelNode, error) {
+	var n KernelNode
+	err := row.Scan(&n.NodeID, &n.Signer, &n.Payee, &n.State, &n.Timestamp, &n.Transaction, &n.CreatedAt, &n.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &n, err
+}
+
+func kernelNodeEventFromRow(row store.Row) (*KernelNodeEvent, error) {
+	var e KernelNodeEvent
+	err := row.Scan(&e.EventID, &e.NodeID, &e.Signer, &e.Payee, &e.State, &e.Timestamp, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &e, err
+}
+
+// SyncKernelNodes stores the kernel node list and records an event whenever
+// the state, signer, payee or state timestamp of a node changes.
+func SyncKernelNodes(ctx context.Context) ([]*KernelNodeEvent, error) {
+	nodes, err := session.Kernel(ctx).ListAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	var events []*KernelNodeEvent
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		t := time.Now()
+		for _, n := range nodes {
+			event, err := syncKernelNode(ctx, tx, n, t)
+			if err != nil {
+				return err
+			} else if event != nil {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return events, nil
+}
+
+func syncKernelNode(ctx context.Context, tx *sql.Tx, n *externals.Node, t time.Time) (*KernelNodeEvent, error) {
+	old, err := findKernelNode(ctx, tx, n.Id)
+	if err != nil {
+		return nil, err
+	}
+	node := &KernelNode{
+		NodeID:      n.Id,
+		Signer:      n.Signer,
+		Payee:       n.Payee,
+		State:       n.State,
+		Timestamp:   n.Timestamp,
+		Transaction: n.Transaction,
+		CreatedAt:   t,
+		UpdatedAt:   t,
+	}
+	if old == nil {
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("kernel_nodes", kernelNodesColumns), node.values()...)
+	} else if old.State != node.State || old.Signer != node.Signer || old.Payee != node.Payee || old.Timestamp != node.Timestamp {
+		_, err = tx.ExecContext(ctx, "UPDATE kernel_nodes SET signer=?,payee=?,state=?,timestamp=?,transaction_hash=?,updated_at=? WHERE node_id=?",
+			node.Signer, node.Payee, node.State, node.Timestamp, node.Transaction, node.UpdatedAt, node.NodeID)
+	} else {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := &KernelNodeEvent{
+		EventID:   bot.UniqueObjectId(node.NodeID, node.Signer, node.Payee, node.State, fmt.Sprint(node.Timestamp)),
+		NodeID:    node.NodeID,
+		Signer:    node.Signer,
+		Payee:     node.Payee,
+		State:     node.State,
+		Timestamp: node.Timestamp,
+		CreatedAt: t,
+	}
+	query := store.BuildInsertionSQL("kernel_node_events", kernelNodeEventsColumns) + " ON CONFLICT (event_id) DO NOTHING"
+	_, err = tx.ExecContext(ctx, query, event.values()...)
+	return event, err
+}
+
+func ReadKernelNode(ctx context.Context, id string) (*KernelNode, error) {
+	var node *KernelNode
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		old, err := findKernelNode(ctx, tx, id)
+		node = old
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return node, nil
+}
+
+func ReadKernelNodes(ctx context.Context) ([]*KernelNode, error) {
+	var nodes []*KernelNode
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM kernel_nodes ORDER BY node_id", strings.Join(kernelNodesColumns, ","))
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			node, err := kernelNodeFromRow(rows)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, node)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return nodes, nil
+}
+
+func ReadKernelNodeEvents(ctx context.Context, id string) ([]*KernelNodeEvent, error) {
+	var events []*KernelNodeEvent
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM kernel_node_events WHERE node_id=? ORDER BY created_at,timestamp", strings.Join(kernelNodeEventsColumns, ","))
+		rows, err := tx.QueryContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			event, err := kernelNodeEventFromRow(rows)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return events, nil
+}
+
+// Changes lists the fields that differ from the previous event of the same
+// node, a first event has no previous and reports all of them.
+func (e *KernelNodeEvent) Changes(prev *KernelNodeEvent) []string {
+	if prev == nil {
+		return []string{"state", "signer", "payee"}
+	}
+	var changes []string
+	if e.State != prev.State {
+		changes = append(changes, "state")
+	}
+	if e.Signer != prev.Signer {
+		changes = append(changes, "signer")
+	}
+	if e.Payee != prev.Payee {
+		changes = append(changes, "payee")
+	}
+	if len(changes) == 0 && e.Timestamp != prev.Timestamp {
+		changes = append(changes, "timestamp")
+	}
+	return changes
+}
+
+// kernelNodeRemovedAt returns when the node entered its current REMOVED state according
+// to the recorded history, the kernel timestamp is preferred when present.
+func kernelNodeRemovedAt(events []*KernelNodeEvent) time.Time {
+	var removed time.Time
+	for _, e := range events {
+		if e.State != KernelNodeStateRemoved {
+			removed = time.Time{}
+			continue
+		}
+		if !removed.IsZero() {
+			continue
+		}
+		removed = e.CreatedAt
+		if e.Timestamp > 0 {
+			removed = time.Unix(0, e.Timestamp)
+		}
+	}
+	return removed
+}
+
+// findKernelNodeSigner looks up the registry, and syncs it from the kernel
+// once if the node has not been recorded yet.
+func findKernelNodeSigner(ctx context.Context, id, payee string) (string, error) {
+	node, err := ReadKernelNode(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if node == nil {
+		_, err = SyncKernelNodes(ctx)
+		if err != nil {
+			return "", err
+		}
+		node, err = ReadKernelNode(ctx, id)
+		if err != nil || node == nil {
+			return "", err
+		}
+	}
+	if node.Payee != payee {
+		return "", nil
+	}
+	switch node.State {
+	case KernelNodeStateAccepted:
+		return node.Signer, nil
+	case KernelNodeStateRemoved:
+		events, err := ReadKernelNodeEvents(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		removed := kernelNodeRemovedAt(events)
+		if !removed.IsZero() && removed.Add(externals.RemovedNodeGracePeriod).After(time.Now()) {
+			return node.Signer, nil
+		}
+	}
+	return "", nil
+}
+
+func findKernelNode(ctx context.Context, tx *sql.Tx, id string) (*KernelNode, error) {
+	query := fmt.Sprintf("SELECT %s FROM kernel_nodes WHERE node_id=?", strings.Join(kernelNodesColumns, ","))
+	return kernelNodeFromRow(tx.QueryRowContext(ctx, query, id))
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKernelNodes(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	id := "394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144"
+	payee := "XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4"
+	signer := "XIN4qtYcAuAsJFnHp61waUheVsiK1byouLqbhrA8VpSQwxHs4z8LPjpFRrx3zdmiXZuFSwJ8CAMCwLkxap1LbRWHk2iVsLyx"
+	kernel := session.Kernel(ctx).(*externals.FakeKernelClient)
+
+	events, err := SyncKernelNodes(ctx)
+	assert.Nil(err)
+	assert.NotEmpty(events)
+	events, err = SyncKernelNodes(ctx)
+	assert.Nil(err)
+	assert.Empty(events)
+
+	node, err := ReadKernelNode(ctx, id)
+	assert.Nil(err)
+	assert.Equal(KernelNodeStateAccepted, node.State)
+	assert.Equal(payee, node.Payee)
+	s, err := findKernelNodeSigner(ctx, id, payee)
+	assert.Nil(err)
+	assert.Equal(signer, s)
+	s, err = findKernelNodeSigner(ctx, id, signer)
+	assert.Nil(err)
+	assert.Equal("", s)
+
+	removed := time.Now().Add(-externals.RemovedNodeGracePeriod - time.Hour)
+	kernel.SetNodes([]*externals.Node{{Id: id, Signer: signer, Payee: payee, State: KernelNodeStateRemoved, Timestamp: removed.UnixNano()}})
+	events, err = SyncKernelNodes(ctx)
+	assert.Nil(err)
+	assert.Len(events, 1)
+	s, err = findKernelNodeSigner(ctx, id, payee)
+	assert.Nil(err)
+	assert.Equal("", s)
+
+	// a later REMOVED timestamp must not extend the grace period
+	kernel.SetNodes([]*externals.Node{{Id: id, Signer: signer, Payee: payee, State: KernelNodeStateRemoved, Timestamp: time.Now().UnixNano()}})
+	_, err = SyncKernelNodes(ctx)
+	assert.Nil(err)
+	s, err = findKernelNodeSigner(ctx, id, payee)
+	assert.Nil(err)
+	assert.Equal("", s)
+
+	history, err := ReadKernelNodeEvents(ctx, id)
+	assert.Nil(err)
+	assert.Len(history, 3)
+	assert.Equal(KernelNodeStateAccepted, history[0].State)
+	assert.Equal(KernelNodeStateRemoved, history[2].State)
+	assert.Equal([]string{"state", "signer", "payee"}, history[0].Changes(nil))
+	assert.Equal([]string{"state"}, history[1].Changes(history[0]))
+	assert.Equal([]string{"timestamp"}, history[2].Changes(history[1]))
+
+	nodes, err := ReadKernelNodes(ctx)
+	assert.Nil(err)
+	assert.NotEmpty(nodes)
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	keystoreVersionGCM = 2
+	keystoreKDFInfo    = "MIXIN SAFE GOVERNANCE KEYSTORE V2"
+)
+
+var keystoreMagic = []byte("MSGK")
+
+// EncryptKeystore seals the app keystore as magic || version || nonce || ciphertext,
+// with an AES-256-GCM key derived by HKDF from the custodian and bot shared secret.
+func EncryptKeystore(shared, plain []byte) ([]byte, error) {
+	return sealEnvelope(shared, keystoreKDFInfo, plain)
+}
+
+// DecryptKeystore opens both the versioned envelope and the legacy bare AES-CBC keystores.
+func DecryptKeystore(shared, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, keystoreMagic) || len(data) <= len(keystoreMagic) {
+		return AesDecryptCBC(shared, data)
+	}
+	return openEnvelope(shared, keystoreKDFInfo, data)
+}
+
+func sealEnvelope(secret []byte, info string, plain []byte) ([]byte, error) {
+	aead, err := envelopeAEAD(secret, info)
+	if err != nil {
+		return nil, err
+	}
+	header := append(append([]byte{}, keystoreMagic...), keystoreVersionGCM)
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	sealed := append(header, nonce...)
+	return aead.Seal(sealed, nonce, plain, header), nil
+}
+
+func openEnvelope(secret []byte, info string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, keystoreMagic) || len(data) <= len(keystoreMagic) {
+		return nil, fmt.Errorf("keystore invalid header")
+	}
+	header := data[:len(keystoreMagic)+1]
+	switch version := header[len(keystoreMagic)]; version {
+	case keystoreVersionGCM:
+		aead, err := envelopeAEAD(secret, info)
+		if err != nil {
+			return nil, err
+		}
+		data = data[len(header):]
+		if len(data) < aead.NonceSize()+aead.Overhead() {
+			return nil, fmt.Errorf("keystore invalid length %d", len(data))
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		plain, err := aead.Open(nil, nonce, ciphertext, header)
+		if err != nil {
+			return nil, fmt.Errorf("keystore authentication failed")
+		}
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("keystore version %d not supported", version)
+	}
+}
+
+func envelopeAEAD(secret []byte, info string) (cipher.AEAD, error) {
+	key := make([]byte, 32)
+	kdf := hkdf.New(sha256.New, secret, nil, []byte(info))
+	_, err := io.ReadFull(kdf, key)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
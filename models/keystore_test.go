@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeystore(t *testing.T) {
+	assert := assert.New(t)
+
+	custodian, _ := crypto.KeyFromString("bdfe0792f1d613d7842587e6bce8a05e549876b5a840c47a0577b0540864ba0e")
+	bot, _ := crypto.KeyFromString("f38222cdd1c17bbf748afa4b74c829785b4af24ea3b5b2172db04f413adc260c")
+	custodianPub, botPub := custodian.Public(), bot.Public()
+	shared := crypto.KeyMultPubPriv(&custodianPub, &bot).Bytes()
+	assert.Equal(shared, crypto.KeyMultPubPriv(&botPub, &custodian).Bytes())
+
+	plain := []byte(`{"app_id":"f857e241-9f04-4c55-b3ca-48bfda6675df"}`)
+	sealed, err := EncryptKeystore(shared, plain)
+	assert.Nil(err)
+	opened, err := DecryptKeystore(crypto.KeyMultPubPriv(&botPub, &custodian).Bytes(), sealed)
+	assert.Nil(err)
+	assert.Equal(plain, opened)
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = DecryptKeystore(shared, tampered)
+	assert.NotNil(err)
+	_, err = DecryptKeystore(custodian[:], sealed)
+	assert.NotNil(err)
+
+	legacy := AesEncryptCBC(shared, append([]byte{}, plain...))
+	opened, err = DecryptKeystore(shared, legacy)
+	assert.Nil(err)
+	assert.Equal(plain, opened)
+	_, err = DecryptKeystore(shared, legacy[:16])
+	assert.NotNil(err)
+	_, err = AesDecryptCBC(shared[:7], legacy)
+	assert.NotNil(err)
+}
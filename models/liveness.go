@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	LivenessKindKernel = "kernel"
+	LivenessKindSafe   = "safe"
+
+	livenessWeeksLimit = 52
+	livenessDomain     = "SAFE-GOVERNANCE-LIVENESS"
+)
+
+type NodeLiveness struct {
+	Custodian        string
+	Week             string
+	KernelSignatures int
+	SafeSignatures   int
+	UpdatedAt        time.Time
+}
+
+type LivenessWeek struct {
+	Week             string
+	Start            time.Time
+	KernelSignatures int
+	SafeSignatures   int
+	Complete         bool
+	Missed           bool
+}
+
+var nodeLivenessColumns = []string{"custodian", "week", "kernel_signatures", "safe_signatures", "updated_at"}
+
+func nodeLivenessFromRow(row store.Row) (*NodeLiveness, error) {
+	var l NodeLiveness
+	err := row.Scan(&l.Custodian, &l.Week, &l.KernelSignatures, &l.SafeSignatures, &l.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &l, err
+}
+
+func LivenessWeekName(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func LivenessDayName(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// LivenessSigningMessage is what the node signs with its custodian key to
+// report Safe liveness on the UTC day.
+func LivenessSigningMessage(custodian, day string) []byte {
+	msg := crypto.NewHash([]byte(livenessDomain + custodian + day))
+	return msg[:]
+}
+
+func livenessWeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-day, 0, 0, 0, 0, time.UTC)
+}
+
+// RecordLivenessSignature counts the signature id once in the weekly counters
+// of the custodian, and returns false if it has been recorded already.
+func RecordLivenessSignature(ctx context.Context, custodian, kind, id string, t time.Time) (bool, error) {
+	if kind != LivenessKindKernel && kind != LivenessKindSafe {
+		return false, session.BadDataError(ctx)
+	}
+	var recorded bool
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		week, now := LivenessWeekName(t), time.Now()
+		query := "INSERT INTO liveness_signatures (signature_id,custodian,kind,week,signed_at,created_at) VALUES (?,?,?,?,?,?) ON CONFLICT (signature_id) DO NOTHING"
+		res, err := tx.ExecContext(ctx, query, bot.UniqueObjectId(kind, id), custodian, kind, week, t.UTC(), now)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil || rows == 0 {
+			return err
+		}
+		kernel, safe := 0, 0
+		if kind == LivenessKindKernel {
+			kernel = 1
+		} else {
+			safe = 1
+		}
+		query = store.BuildInsertionSQL("node_liveness", nodeLivenessColumns) + " ON CONFLICT (custodian, week) DO UPDATE SET " +
+			"kernel_signatures=kernel_signatures+excluded.kernel_signatures,safe_signatures=safe_signatures+excluded.safe_signatures,updated_at=excluded.updated_at"
+		_, err = tx.ExecContext(ctx, query, custodian, week, kernel, safe, now)
+		recorded = err == nil
+		return err
+	})
+	if err != nil {
+		return false, session.TransactionError(ctx, err)
+	}
+	return recorded, nil
+}
+
+// RecordSafeSignature counts the Safe liveness of the custodian on the day of
+// t, the signature must be made by the custodian key over the signing message
+// of that day, and each day is counted once.
+func RecordSafeSignature(ctx context.Context, custodian, day, signature string, t time.Time) (bool, error) {
+	if day != LivenessDayName(t) {
+		return false, session.BadDataErrorWithFieldAndData(ctx, "day", "invalid", day)
+	}
+	if !verifyCustodianSignature(custodian, signature, LivenessSigningMessage(custodian, day)) {
+		return false, session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+	}
+	return RecordLivenessSignature(ctx, custodian, LivenessKindSafe, custodian+day, t)
+}
+
+// ReadNodeLiveness lists the weekly counters of the node since its first full
+// week, a complete week without both kernel and safe signatures is missed.
+func ReadNodeLiveness(ctx context.Context, node *Node, now time.Time) ([]*LivenessWeek, error) {
+	counters := make(map[string]*NodeLiveness)
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM node_liveness WHERE custodian=?", strings.Join(nodeLivenessColumns, ","))
+		rows, err := tx.QueryContext(ctx, query, node.Custodian)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			l, err := nodeLivenessFromRow(rows)
+			if err != nil {
+				return err
+			}
+			counters[l.Week] = l
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+
+	start := livenessWeekStart(node.CreatedAt).AddDate(0, 0, 7)
+	if oldest := livenessWeekStart(now).AddDate(0, 0, -7*(livenessWeeksLimit-1)); start.Before(oldest) {
+		start = oldest
+	}
+	var weeks []*LivenessWeek
+	for t := start; !t.After(now); t = t.AddDate(0, 0, 7) {
+		w := &LivenessWeek{
+			Week:     LivenessWeekName(t),
+			Start:    t,
+			Complete: !t.AddDate(0, 0, 7).After(now),
+		}
+		if l := counters[w.Week]; l != nil {
+			w.KernelSignatures = l.KernelSignatures
+			w.SafeSignatures = l.SafeSignatures
+		}
+		w.Missed = w.Complete && (w.KernelSignatures == 0 || w.SafeSignatures == 0)
+		weeks = append(weeks, w)
+	}
+	return weeks, nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeLiveness(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	node, err := CreateNode(ctx, "custodian", "payee", "kernel", "app", "hash")
+	assert.Nil(err)
+	assert.Equal("2026-W42", LivenessWeekName(time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), livenessWeekStart(time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)))
+
+	node.CreatedAt = time.Date(2026, 9, 30, 8, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 21, 8, 0, 0, 0, time.UTC)
+	for _, d := range []int{6, 7, 13} {
+		recorded, err := RecordLivenessSignature(ctx, node.Custodian, LivenessKindKernel, fmt.Sprintf("kernel-%d", d), time.Date(2026, 10, d, 1, 0, 0, 0, time.UTC))
+		assert.Nil(err)
+		assert.True(recorded)
+	}
+	recorded, err := RecordLivenessSignature(ctx, node.Custodian, LivenessKindKernel, "kernel-6", time.Date(2026, 10, 6, 1, 0, 0, 0, time.UTC))
+	assert.Nil(err)
+	assert.False(recorded)
+	recorded, err = RecordLivenessSignature(ctx, node.Custodian, LivenessKindSafe, "safe-a", time.Date(2026, 10, 8, 1, 0, 0, 0, time.UTC))
+	assert.Nil(err)
+	assert.True(recorded)
+	_, err = RecordLivenessSignature(ctx, node.Custodian, "unknown", "x", now)
+	assert.NotNil(err)
+
+	weeks, err := ReadNodeLiveness(ctx, node, now)
+	assert.Nil(err)
+	assert.Len(weeks, 3)
+	assert.Equal("2026-W41", weeks[0].Week)
+	assert.Equal(2, weeks[0].KernelSignatures)
+	assert.Equal(1, weeks[0].SafeSignatures)
+	assert.False(weeks[0].Missed)
+	assert.Equal("2026-W42", weeks[1].Week)
+	assert.Equal(1, weeks[1].KernelSignatures)
+	assert.True(weeks[1].Missed)
+	assert.False(weeks[2].Complete)
+	assert.False(weeks[2].Missed)
+}
+
+func TestSafeSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	key, custodian := testCustodianKey()
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	day := LivenessDayName(now)
+	assert.Equal("2026-10-18", day)
+
+	sig := key.Sign(LivenessSigningMessage(custodian, day))
+	_, err := RecordSafeSignature(ctx, custodian, day, "invalid", now)
+	assert.NotNil(err)
+	_, err = RecordSafeSignature(ctx, custodian, day, sig.String(), now.AddDate(0, 0, 1))
+	assert.NotNil(err)
+	other := key.Sign(LivenessSigningMessage(custodian, "2026-10-19"))
+	_, err = RecordSafeSignature(ctx, custodian, day, other.String(), now)
+	assert.NotNil(err)
+
+	recorded, err := RecordSafeSignature(ctx, custodian, day, sig.String(), now)
+	assert.Nil(err)
+	assert.True(recorded)
+	recorded, err = RecordSafeSignature(ctx, custodian, day, sig.String(), now.Add(time.Hour))
+	assert.Nil(err)
+	assert.False(recorded)
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/MixinNetwork/safe/governance/config"
-	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/extra"
 	"github.com/MixinNetwork/safe/governance/session"
 	"github.com/MixinNetwork/safe/governance/store"
 	"github.com/gofrs/uuid"
@@ -34,19 +34,21 @@ type Node struct {
 	MixinHash sql.NullString
 	Keystore  string
 	PublicKey string
+	State     string
+	Epoch     string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-var nodesColumns = []string{"custodian", "payee", "kernel_id", "app_id", "mixin_hash", "keystore", "public_key", "created_at", "updated_at"}
+var nodesColumns = []string{"custodian", "payee", "kernel_id", "app_id", "mixin_hash", "keystore", "public_key", "state", "epoch", "created_at", "updated_at"}
 
 func (n *Node) values() []any {
-	return []any{n.Custodian, n.Payee, n.KernelID, n.AppID, n.MixinHash, n.Keystore, n.PublicKey, n.CreatedAt, n.UpdatedAt}
+	return []any{n.Custodian, n.Payee, n.KernelID, n.AppID, n.MixinHash, n.Keystore, n.PublicKey, n.State, n.Epoch, n.CreatedAt, n.UpdatedAt}
 }
 
 func nodeFromRow(row store.Row) (*Node, error) {
 	var n Node
-	err := row.Scan(&n.Custodian, &n.Payee, &n.KernelID, &n.AppID, &n.MixinHash, &n.Keystore, &n.PublicKey, &n.CreatedAt, &n.UpdatedAt)
+	err := row.Scan(&n.Custodian, &n.Payee, &n.KernelID, &n.AppID, &n.MixinHash, &n.Keystore, &n.PublicKey, &n.State, &n.Epoch, &n.CreatedAt, &n.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -62,6 +64,8 @@ func CreateNode(ctx context.Context, custodian, payee, kernelID, appID, hash str
 		AppID:     sql.NullString{String: appID, Valid: true},
 		MixinHash: sql.NullString{String: hash, Valid: true},
 		Keystore:  "",
+		State:     NodeStateAppAssigned,
+		Epoch:     currentEpochName(t),
 		CreatedAt: t,
 		UpdatedAt: t,
 	}
@@ -78,6 +82,10 @@ func CreateNode(ctx context.Context, custodian, payee, kernelID, appID, hash str
 
 // extra: custodian (common.Address) || payee (common.Address) || node id (crypto.Hash)
 func CreateNodeByExtra(ctx context.Context, extra string) (*Node, error) {
+	err := session.Database(ctx).RunInTransaction(ctx, checkRegistrationEpoch)
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
 	custodian, payee, kernel, err := validateExtra(ctx, extra)
 	if err != nil {
 		return nil, err
@@ -87,6 +95,7 @@ func CreateNodeByExtra(ctx context.Context, extra string) (*Node, error) {
 		Custodian: custodian.String(),
 		Payee:     payee.String(),
 		KernelID:  kernel.String(),
+		State:     NodeStateRegistered,
 		CreatedAt: t,
 		UpdatedAt: t,
 	}
@@ -109,6 +118,10 @@ func CreateNodeByExtra(ctx context.Context, extra string) (*Node, error) {
 	node.MixinHash = sql.NullString{String: snapshot.TransactionHash, Valid: true}
 
 	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM nodes WHERE state=? AND (custodian=? OR payee=? OR kernel_id=?)", NodeStateExpired, node.Custodian, node.Payee, node.KernelID)
+		if err != nil {
+			return err
+		}
 		old, err := findNode(ctx, tx, node.Custodian, node.Payee, node.KernelID, node.AppID.String, "")
 		if err != nil {
 			return err
@@ -126,16 +139,13 @@ func CreateNodeByExtra(ctx context.Context, extra string) (*Node, error) {
 	return node, nil
 }
 
-func PaymentNode(ctx context.Context, hash string) (*Node, error) {
-	set, err := ReadNodeSet(ctx)
-	if err != nil {
-		return nil, err
-	}
-	apps, err := config.FetchApps()
-	if err != nil {
-		return nil, err
-	}
-	transaction, err := externals.ReadTransaction(hash)
+// PaymentNode assigns an app to the node paid by the transfer, whose memo is
+// the node mixin hash, and records the transfer as the node fee to burn. The
+// extra is fully validated at registration, so only its match with the node
+// is checked here and a node paid after the extra expired is still accepted.
+func PaymentNode(ctx context.Context, transfer *bot.TransferView) (*Node, error) {
+	hash := transfer.Memo
+	transaction, err := session.Kernel(ctx).ReadTransaction(hash)
 	if err != nil {
 		return nil, err
 	} else if transaction == nil {
@@ -146,9 +156,9 @@ func PaymentNode(ctx context.Context, hash string) (*Node, error) {
 		return nil, err
 	}
 	pack := bot.DecodeMixinExtra(extraBuf)
-	_, _, _, err = validateExtra(ctx, pack.M)
+	e, err := extra.DecodeString(pack.M)
 	if err != nil {
-		return nil, err
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "extra", "invalid", pack.M)
 	}
 
 	var node *Node
@@ -161,17 +171,26 @@ func PaymentNode(ctx context.Context, hash string) (*Node, error) {
 		if node.AppID.String != "" {
 			return nil
 		}
-		var app *config.App
-		for _, a := range apps {
-			if set[a.AppID] != nil {
-				continue
-			}
-			app = a
-			node.AppID = sql.NullString{String: app.AppID, Valid: true}
+		if node.Custodian != e.Custodian.String() || node.Payee != e.Payee.String() || node.KernelID != e.NodeID.String() {
+			return session.BadDataErrorWithFieldAndData(ctx, "extra", "mismatched", pack.M)
 		}
-		if node.AppID.String == "" {
-			return session.BadDataErrorWithFieldAndData(ctx, "app id", "invalid", "")
+		err = node.transition(ctx, NodeStatePaid)
+		if err != nil {
+			return err
+		}
+		err = checkRegistrationEpoch(ctx, tx)
+		if err != nil {
+			return err
+		}
+		seat, err := reserveAppSeat(ctx, tx, node.Custodian)
+		if err != nil {
+			return err
+		}
+		app, err := seat.App()
+		if err != nil {
+			return err
 		}
+		node.AppID = sql.NullString{String: app.AppID, Valid: true}
 
 		appBuf, err := json.Marshal(app)
 		if err != nil {
@@ -186,12 +205,23 @@ func PaymentNode(ctx context.Context, hash string) (*Node, error) {
 		privateBuf, _ := base64.RawURLEncoding.DecodeString(mixin.PrivateKey)
 		privateBot := crypto.NewKeyFromSeed(privateBuf)
 		key := crypto.KeyMultPubPriv(&custodian.PublicSpendKey, &privateBot)
-		encryptedBuf := AesEncryptCBC(key.Bytes(), appBuf)
+		encryptedBuf, err := EncryptKeystore(key.Bytes(), appBuf)
+		if err != nil {
+			return err
+		}
 		node.Keystore = base64.RawURLEncoding.EncodeToString(encryptedBuf)
 		node.PublicKey = privateBot.Public().String()
+		node.Epoch = currentEpochName(time.Now())
+		err = node.transition(ctx, NodeStateAppAssigned)
+		if err != nil {
+			return err
+		}
 
-		_, err = tx.ExecContext(ctx, "UPDATE nodes SET app_id=?,keystore=?,public_key=? WHERE custodian=?", node.AppID, base64.RawURLEncoding.EncodeToString(encryptedBuf), node.PublicKey, node.Custodian)
-		return err
+		_, err = tx.ExecContext(ctx, "UPDATE nodes SET app_id=?,keystore=?,public_key=?,state=?,epoch=?,updated_at=? WHERE custodian=?", node.AppID, node.Keystore, node.PublicKey, node.State, node.Epoch, node.UpdatedAt, node.Custodian)
+		if err != nil {
+			return err
+		}
+		return writeNodeFee(ctx, tx, node.Custodian, transfer)
 	})
 	if err != nil {
 		return nil, session.TransactionError(ctx, err)
@@ -200,13 +230,47 @@ func PaymentNode(ctx context.Context, hash string) (*Node, error) {
 }
 
 func ReadNodes(ctx context.Context) ([]*Node, error) {
+	query := fmt.Sprintf("SELECT %s FROM nodes WHERE app_id IS NOT NULL LIMIT 100", strings.Join(nodesColumns, ","))
+	return readNodes(ctx, query)
+}
+
+func ReadNodesByState(ctx context.Context, state string) ([]*Node, error) {
+	if !ValidNodeState(state) {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "state", "invalid", state)
+	}
+	query := fmt.Sprintf("SELECT %s FROM nodes WHERE state=? LIMIT 100", strings.Join(nodesColumns, ","))
+	return readNodes(ctx, query, state)
+}
+
+// readAllNodesByState reads the whole set of nodes in state without any limit,
+// and fails if the rows read do not match the count of the state.
+func readAllNodesByState(ctx context.Context, state string) ([]*Node, error) {
+	var count int
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes WHERE state=?", state).Scan(&count)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	query := fmt.Sprintf("SELECT %s FROM nodes WHERE state=? ORDER BY custodian", strings.Join(nodesColumns, ","))
+	nodes, err := readNodes(ctx, query, state)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) != count {
+		return nil, session.ServerError(ctx, fmt.Errorf("nodes in state %s read %d of %d", state, len(nodes), count))
+	}
+	return nodes, nil
+}
+
+func readNodes(ctx context.Context, query string, args ...any) ([]*Node, error) {
 	var nodes []*Node
 	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-		query := fmt.Sprintf("SELECT %s FROM nodes WHERE app_id IS NOT NULL LIMIT 100", strings.Join(nodesColumns, ","))
-		rows, err := tx.QueryContext(ctx, query)
+		rows, err := tx.QueryContext(ctx, query, args...)
 		if err != nil {
 			return err
 		}
+		defer rows.Close()
 		for rows.Next() {
 			node, err := nodeFromRow(rows)
 			if err != nil {
@@ -234,6 +298,18 @@ func ReadNodeSet(ctx context.Context) (map[string]*Node, error) {
 	return set, nil
 }
 
+func ReadNodeSetByKernel(ctx context.Context) (map[string]*Node, error) {
+	nodes, err := ReadNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]*Node, 0)
+	for _, n := range nodes {
+		set[n.KernelID] = n
+	}
+	return set, nil
+}
+
 func ReadNode(ctx context.Context, custodian string) (*Node, error) {
 	var node *Node
 	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
@@ -247,6 +323,19 @@ func ReadNode(ctx context.Context, custodian string) (*Node, error) {
 	return node, nil
 }
 
+func ReadNodeByHash(ctx context.Context, hash string) (*Node, error) {
+	var node *Node
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		old, err := findNode(ctx, tx, "", "", "", "", hash)
+		node = old
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return node, nil
+}
+
 func findNode(ctx context.Context, tx *sql.Tx, custodian, payee, kernel, app, hash string) (*Node, error) {
 	query := fmt.Sprintf("SELECT %s FROM nodes WHERE custodian=? OR payee=? OR kernel_id=? OR app_id=? OR mixin_hash=?", strings.Join(nodesColumns, ","))
 	row := tx.QueryRowContext(ctx, query, custodian, payee, kernel, app, hash)
@@ -254,89 +343,79 @@ func findNode(ctx context.Context, tx *sql.Tx, custodian, payee, kernel, app, ha
 	return node, err
 }
 
-func validateExtra(ctx context.Context, extra string) (*common.Address, *common.Address, *crypto.Hash, error) {
-	raw, err := base64.RawURLEncoding.DecodeString(extra)
+func validateExtra(ctx context.Context, memo string) (*common.Address, *common.Address, *crypto.Hash, error) {
+	e, err := extra.DecodeString(memo)
 	if err != nil {
-		return nil, nil, nil, err
-	}
-	if len(raw) != 353 {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "extra", "invalid", extra)
+		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "extra", "invalid", memo)
 	}
-	if raw[0] != byte(1) {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "extra head", "invalid", extra)
+	if e.Version == extra.Version2 {
+		if e.NetworkID != networkID() {
+			return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "extra network", "invalid", memo)
+		}
+		if !e.ExpireAt.After(time.Now()) {
+			return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "extra expire", "expired", memo)
+		}
 	}
-	custodianBytes := raw[1:65]
-	payeeBytes := raw[65:129]
-	nodeBytes := raw[129:161]
-	sigSignerBytes := raw[161:225]
-	sigPayeeBytes := raw[225:289]
-	sigCustodianBytes := raw[289:]
-
-	custodian := common.Address{}
-	copy(custodian.PublicSpendKey[:], custodianBytes[:32])
-	copy(custodian.PublicViewKey[:], custodianBytes[32:])
-
-	payee := common.Address{}
-	copy(payee.PublicSpendKey[:], payeeBytes[:32])
-	copy(payee.PublicViewKey[:], payeeBytes[32:])
-
-	var kernel crypto.Hash
-	copy(kernel[:], nodeBytes)
-
-	var sigSigner crypto.Signature
-	copy(sigSigner[:], sigSignerBytes)
+	custodian, payee, kernel := e.Custodian, e.Payee, e.NodeID
 
-	var sigPayee crypto.Signature
-	copy(sigPayee[:], sigPayeeBytes)
-
-	var signCustodian crypto.Signature
-	copy(signCustodian[:], sigCustodianBytes)
-
-	nodes, err := externals.ListAllNodes()
+	signerStr, err := findKernelNodeSigner(ctx, kernel.String(), payee.String())
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	var signerStr string
-	for _, n := range nodes {
-		if n.Id == kernel.String() {
-			if n.State == "ACCEPTED" {
-				if n.Payee == payee.String() {
-					signerStr = n.Signer
-				}
-				break
-			}
-			if n.State == "REMOVED" {
-				t := time.Unix(0, n.Timestamp)
-				if t.Add(7 * 24 * time.Hour).After(time.Now()) {
-					if n.Payee == payee.String() {
-						signerStr = n.Signer
-					}
-					break
-				}
-			}
-		}
-	}
 	if signerStr == "" {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "not existing", extra)
+		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "not existing", memo)
 	}
 
 	signer, err := common.NewAddressFromString(signerStr)
 	if err != nil {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "invalid", extra)
+		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "signer", "invalid", memo)
 	}
 
-	if !signer.PublicSpendKey.Verify(raw[:161], sigSigner) {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "signer signature verify", "invalid", extra)
+	err = e.Verify(signer)
+	if err != nil {
+		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "signature verify", err.Error(), memo)
+	}
+	if e.Version == extra.Version2 {
+		err = useExtraNonce(ctx, e, memo)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 	}
+	return &custodian, &payee, &kernel, nil
+}
 
-	if !payee.PublicSpendKey.Verify(raw[:161], sigPayee) {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "payee signature verify", "invalid", extra)
+// useExtraNonce binds the nonce of the network to the payload of the first
+// valid extra using it, the same extra can be validated again while any other
+// payload with the nonce is rejected.
+func useExtraNonce(ctx context.Context, e *extra.Extra, memo string) error {
+	network, nonce := e.NetworkID.String(), hex.EncodeToString(e.Nonce[:])
+	hash := crypto.NewHash(e.Payload()).String()
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var used string
+		err := tx.QueryRowContext(ctx, "SELECT payload_hash FROM extra_nonces WHERE network_id=? AND nonce=?", network, nonce).Scan(&used)
+		if err == sql.ErrNoRows {
+			_, err = tx.ExecContext(ctx, "INSERT INTO extra_nonces (network_id,nonce,payload_hash,created_at) VALUES (?,?,?,?)", network, nonce, hash, time.Now())
+			return err
+		} else if err != nil {
+			return err
+		}
+		if used != hash {
+			return session.BadDataErrorWithFieldAndData(ctx, "extra nonce", "used", memo)
+		}
+		return nil
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
 	}
+	return nil
+}
 
-	if !custodian.PublicSpendKey.Verify(raw[:161], signCustodian) {
-		return nil, nil, nil, session.BadDataErrorWithFieldAndData(ctx, "custodian signature verify", "invalid", extra)
+func networkID() crypto.Hash {
+	governance := config.AppConfig.Governance
+	if id, err := crypto.HashFromString(governance.NetworkID); err == nil {
+		return id
 	}
-	return &custodian, &payee, &kernel, nil
+	return extra.NetworkIDForEnvironment(config.AppConfig.Environment)
 }
 
 func AesEncryptCBC(key, msg []byte) []byte {
@@ -359,23 +438,28 @@ func AesEncryptCBC(key, msg []byte) []byte {
 }
 
 func AesDecryptCBC(key, ciphertext []byte) ([]byte, error) {
-	if cl := len(ciphertext); cl < aes.BlockSize || cl%aes.BlockSize != 0 {
+	if cl := len(ciphertext); cl < aes.BlockSize*2 || cl%aes.BlockSize != 0 {
 		return nil, fmt.Errorf("AES cipher text invalid length %d", cl)
 	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	iv := ciphertext[:aes.BlockSize]
-	source := ciphertext[aes.BlockSize:]
+	source := make([]byte, len(ciphertext)-aes.BlockSize)
 	mode := cipher.NewCBCDecrypter(block, iv)
-	mode.CryptBlocks(source, source)
+	mode.CryptBlocks(source, ciphertext[aes.BlockSize:])
 
 	length := len(source)
 	unpadding := int(source[length-1])
-	if unpadding > length {
+	if unpadding == 0 || unpadding > aes.BlockSize {
 		return nil, fmt.Errorf("AES CBC padding invalid %d %d", unpadding, length)
 	}
+	for _, b := range source[length-unpadding:] {
+		if int(b) != unpadding {
+			return nil, fmt.Errorf("AES CBC padding invalid %d %d", unpadding, length)
+		}
+	}
 	return source[:length-unpadding], nil
 }
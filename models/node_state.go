@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+const (
+	NodeStateRegistered        = "registered"
+	NodeStatePaid              = "paid"
+	NodeStateAppAssigned       = "app_assigned"
+	NodeStateKeystoreDelivered = "keystore_delivered"
+	NodeStateMigrated          = "migrated"
+	NodeStateExpired           = "expired"
+	NodeStateRevoked           = "revoked"
+	NodeStateIneligible        = "ineligible"
+
+	NodeRegistrationTimeout = 72 * time.Hour
+
+	nodeStateDomain = "SAFE-GOVERNANCE-NODE-STATE"
+)
+
+var nodeStateTransitions = map[string][]string{
+	NodeStateRegistered:        {NodeStatePaid, NodeStateExpired, NodeStateRevoked},
+	NodeStatePaid:              {NodeStateAppAssigned, NodeStateIneligible, NodeStateRevoked},
+	NodeStateAppAssigned:       {NodeStateKeystoreDelivered, NodeStateIneligible, NodeStateRevoked},
+	NodeStateKeystoreDelivered: {NodeStateMigrated, NodeStateIneligible, NodeStateRevoked},
+	NodeStateMigrated:          {NodeStateIneligible, NodeStateRevoked},
+	NodeStateIneligible:        {NodeStateRevoked},
+	NodeStateExpired:           {},
+	NodeStateRevoked:           {},
+}
+
+var activeNodeStates = []string{NodeStateAppAssigned, NodeStateKeystoreDelivered, NodeStateMigrated}
+
+func ValidNodeState(state string) bool {
+	_, found := nodeStateTransitions[state]
+	return found
+}
+
+func (n *Node) transition(ctx context.Context, state string) error {
+	for _, s := range nodeStateTransitions[n.State] {
+		if s == state {
+			n.State = state
+			n.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return session.InvalidStateTransitionError(ctx, n.State, state)
+}
+
+// NodeStateSigningMessage is what the custodian key signs to confirm that the
+// node app has reached the state, i.e. the keystore has been decrypted or the
+// app key and owner have been migrated to the node operator.
+func NodeStateSigningMessage(custodian, appID, state string) []byte {
+	msg := crypto.NewHash([]byte(nodeStateDomain + custodian + appID + state))
+	return msg[:]
+}
+
+// ConfirmNodeState moves the node to the keystore delivered or migrated state
+// with the custodian signature of the state message.
+func ConfirmNodeState(ctx context.Context, custodian, state, signature string) (*Node, error) {
+	if state != NodeStateKeystoreDelivered && state != NodeStateMigrated {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "state", "invalid", state)
+	}
+	var node *Node
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		old, err := findNode(ctx, tx, custodian, "", "", "", "")
+		if err != nil {
+			return err
+		} else if old == nil || old.Custodian != custodian {
+			return session.NotFoundError(ctx)
+		}
+		node = old
+		if !verifyCustodianSignature(custodian, signature, NodeStateSigningMessage(custodian, node.AppID.String, state)) {
+			return session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+		}
+		err = node.transition(ctx, state)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE nodes SET state=?,updated_at=? WHERE custodian=?", node.State, node.UpdatedAt, node.Custodian)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return node, nil
+}
+
+// ExpireNodes marks the registrations not paid within the timeout as expired,
+// the node can then be registered again with the same keys.
+func ExpireNodes(ctx context.Context, now time.Time) ([]*Node, error) {
+	var expired []*Node
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM nodes WHERE state=?", strings.Join(nodesColumns, ","))
+		rows, err := tx.QueryContext(ctx, query, NodeStateRegistered)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			n, err := nodeFromRow(rows)
+			if err != nil {
+				return err
+			}
+			if now.After(n.CreatedAt.Add(NodeRegistrationTimeout)) {
+				expired = append(expired, n)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, n := range expired {
+			n.State, n.UpdatedAt = NodeStateExpired, now
+			_, err = tx.ExecContext(ctx, "UPDATE nodes SET state=?,updated_at=? WHERE custodian=? AND state=?", n.State, n.UpdatedAt, n.Custodian, NodeStateRegistered)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return expired, nil
+}
+
+func UpdateNodeState(ctx context.Context, custodian, state string) (*Node, error) {
+	var node *Node
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		old, err := findNode(ctx, tx, custodian, "", "", "", "")
+		if err != nil {
+			return err
+		} else if old == nil {
+			return session.NotFoundError(ctx)
+		}
+		node = old
+		err = node.transition(ctx, state)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE nodes SET state=?,updated_at=? WHERE custodian=?", node.State, node.UpdatedAt, node.Custodian)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return node, nil
+}
@@ -1,14 +1,23 @@
 package models
 
 import (
+	"context"
+	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
 	"log"
 	"testing"
+	"time"
 
+	"github.com/MixinNetwork/bot-api-go-client"
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/externals"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -105,7 +114,7 @@ func TestNodeCRUD(t *testing.T) {
 	assert.Equal("", node.AppID.String)
 	return
 
-	node, err = PaymentNode(ctx, "5e7f37fd76bea1647d46c396e21c6496f3033f03ea50121500c6e6c2df5294b7")
+	node, err = PaymentNode(ctx, &bot.TransferView{Memo: "5e7f37fd76bea1647d46c396e21c6496f3033f03ea50121500c6e6c2df5294b7"})
 	assert.Nil(err)
 	assert.NotNil(node)
 	node, err = ReadNode(ctx, node.Custodian)
@@ -114,8 +123,8 @@ func TestNodeCRUD(t *testing.T) {
 	assert.NotEqual("", node.AppID.String)
 	assert.NotEqual("", node.Keystore)
 
-	apps, _ := config.FetchApps()
-	assert.Equal("a9f616af-635a-4047-a32c-526340d3c241", apps[0].AppID)
+	seats, _ := ReadAppSeats(ctx)
+	assert.Equal("a9f616af-635a-4047-a32c-526340d3c241", seats[0].AppID)
 	mixin := config.AppConfig.Mixin
 	privateBuf, _ := base64.RawURLEncoding.DecodeString(mixin.PrivateKey)
 
@@ -133,3 +142,273 @@ func TestNodeCRUD(t *testing.T) {
 	keystoreBuf, _ := AesDecryptCBC(crypto.KeyMultPubPriv(&publicBotKey, &privateCustodian).Bytes(), keystore)
 	log.Println(string(keystoreBuf))
 }
+
+func TestPaymentNodeWithFakeKernel(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	kernel := session.Kernel(ctx).(*externals.FakeKernelClient)
+	extra := buildTestExtra()
+
+	custodian, payee, node, err := validateExtra(ctx, extra)
+	assert.Nil(err)
+	assert.Equal("XINJYiri2BU4dLGdsj33C5pvDuhzxK7DmWB9PvABa7u53tCoabApajFRsNTbsLjm2tjPfRQJEN2Awpe8SP3V35CMGRm2A5N1", custodian.String())
+	assert.Equal("XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4", payee.String())
+	assert.Equal("394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144", node.String())
+
+	kernel.SetNodes([]*externals.Node{{
+		Id:        "394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144",
+		Signer:    "XIN4qtYcAuAsJFnHp61waUheVsiK1byouLqbhrA8VpSQwxHs4z8LPjpFRrx3zdmiXZuFSwJ8CAMCwLkxap1LbRWHk2iVsLyx",
+		Payee:     "XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4",
+		State:     "REMOVED",
+		Timestamp: time.Now().Add(-time.Hour).UnixNano(),
+	}})
+	_, err = SyncKernelNodes(ctx)
+	assert.Nil(err)
+	_, _, _, err = validateExtra(ctx, extra)
+	assert.Nil(err)
+
+	hash := "5e7f37fd76bea1647d46c396e21c6496f3033f03ea50121500c6e6c2df5294b7"
+	now := time.Now()
+	_, err = session.Database(ctx).Query(ctx, "INSERT INTO nodes (custodian,payee,kernel_id,mixin_hash,keystore,public_key,created_at,updated_at) VALUES (?,?,?,?,?,?,?,?)",
+		custodian.String(), payee.String(), node.String(), hash, "", "", now, now)
+	assert.Nil(err)
+	apps := []*config.App{{
+		AppID:      "f857e241-9f04-4c55-b3ca-48bfda6675df",
+		SessionID:  "3a6eabab-0be3-4995-87e0-1cc7aa836b6d",
+		PrivateKey: "private",
+		PinToken:   "token",
+		Pin:        "123456",
+	}}
+	_, err = ImportAppSeats(ctx, apps)
+	assert.Nil(err)
+	kernel.PutTransaction(&externals.Transaction{
+		Hash:  hash,
+		Extra: hex.EncodeToString(bot.EncodeMixinExtra(uuid.Nil.String(), extra)),
+	})
+	transfer := &bot.TransferView{SnapshotId: uuid.Must(uuid.NewV4()).String(), AssetId: config.AppConfig.Governance.FeeAssetID, Amount: "100", Memo: hash}
+	n, err := PaymentNode(ctx, transfer)
+	assert.Nil(err)
+	assert.NotNil(n)
+	assert.NotEqual("", n.AppID.String)
+	assert.NotEqual("", n.Keystore)
+	assert.Equal(NodeStateAppAssigned, n.State)
+	assert.Equal(apps[0].AppID, n.AppID.String)
+	fee, err := ReadNodeFee(ctx, n.Custodian)
+	assert.Nil(err)
+	assert.Equal(transfer.SnapshotId, fee.SnapshotID)
+	assert.Equal("100", fee.Amount)
+}
+
+func buildTestExtra() string {
+	custodian, _ := common.NewAddressFromString("XINJYiri2BU4dLGdsj33C5pvDuhzxK7DmWB9PvABa7u53tCoabApajFRsNTbsLjm2tjPfRQJEN2Awpe8SP3V35CMGRm2A5N1")
+	payee, _ := common.NewAddressFromString("XINYvDWLAqoa1PxNxAaJcecrrehHVaaqqT4owg7ST1Yt2Gs5VUX62ArnVW7rx3vBMxfRdA5Y6kEg1Y5jSdQDFF3msunpmED4")
+	kernel, _ := crypto.HashFromString("394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144")
+
+	extra := []byte{1}
+	extra = append(extra, custodian.PublicSpendKey[:]...)
+	extra = append(extra, custodian.PublicViewKey[:]...)
+	extra = append(extra, payee.PublicSpendKey[:]...)
+	extra = append(extra, payee.PublicViewKey[:]...)
+	extra = append(extra, kernel[:]...)
+	for _, k := range []string{
+		"ed4c90d8a0a34e4a3e564ea1ee5399a14a920a8cc2fdc56be3e5fba88c44350e",
+		"f38222cdd1c17bbf748afa4b74c829785b4af24ea3b5b2172db04f413adc260c",
+		"bdfe0792f1d613d7842587e6bce8a05e549876b5a840c47a0577b0540864ba0e",
+	} {
+		key, _ := crypto.KeyFromString(k)
+		sig := key.Sign(extra[:161])
+		extra = append(extra, sig[:]...)
+	}
+	return base64.RawURLEncoding.EncodeToString(extra)
+}
+
+func TestNodeState(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	node, err := CreateNode(ctx, "custodian", "payee", "kernel", "app", "hash")
+	assert.Nil(err)
+	assert.Equal(NodeStateAppAssigned, node.State)
+
+	_, err = UpdateNodeState(ctx, node.Custodian, NodeStatePaid)
+	serr, ok := err.(*session.Error)
+	assert.True(ok)
+	assert.Equal(10004, serr.Code)
+
+	node, err = UpdateNodeState(ctx, node.Custodian, NodeStateKeystoreDelivered)
+	assert.Nil(err)
+	assert.Equal(NodeStateKeystoreDelivered, node.State)
+	nodes, err := ReadNodesByState(ctx, NodeStateKeystoreDelivered)
+	assert.Nil(err)
+	assert.Len(nodes, 1)
+	nodes, err = ReadNodesByState(ctx, NodeStateAppAssigned)
+	assert.Nil(err)
+	assert.Len(nodes, 0)
+	_, err = ReadNodesByState(ctx, "unknown")
+	assert.NotNil(err)
+}
+
+func TestValidateExtraV2(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	v1, err := extra.DecodeString(buildTestExtra())
+	assert.Nil(err)
+	expire := time.Now().Add(time.Hour)
+	build := func(network crypto.Hash, expire time.Time) string {
+		e := extra.NewExtraV2(v1.Custodian, v1.Payee, v1.NodeID, network, expire, [16]byte{9})
+		for role, k := range map[string]string{
+			extra.RoleSigner:    "ed4c90d8a0a34e4a3e564ea1ee5399a14a920a8cc2fdc56be3e5fba88c44350e",
+			extra.RolePayee:     "f38222cdd1c17bbf748afa4b74c829785b4af24ea3b5b2172db04f413adc260c",
+			extra.RoleCustodian: "bdfe0792f1d613d7842587e6bce8a05e549876b5a840c47a0577b0540864ba0e",
+		} {
+			key, _ := crypto.KeyFromString(k)
+			assert.Nil(e.Sign(role, key))
+		}
+		return e.String()
+	}
+
+	valid := build(networkID(), expire)
+	_, _, _, err = validateExtra(ctx, valid)
+	assert.Nil(err)
+	_, _, _, err = validateExtra(ctx, valid)
+	assert.Nil(err)
+	_, _, _, err = validateExtra(ctx, build(networkID(), expire.Add(time.Hour)))
+	serr, ok := err.(*session.Error)
+	assert.True(ok)
+	assert.Equal(10002, serr.Code)
+	_, _, _, err = validateExtra(ctx, build(networkID(), time.Now().Add(-time.Hour)))
+	assert.NotNil(err)
+	_, _, _, err = validateExtra(ctx, build(extra.NetworkIDForEnvironment("prod"), time.Now().Add(time.Hour)))
+	assert.NotNil(err)
+}
+
+func TestPaymentNodeAfterExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	kernel := session.Kernel(ctx).(*externals.FakeKernelClient)
+	v1, err := extra.DecodeString(buildTestExtra())
+	assert.Nil(err)
+	build := func(custodian common.Address, expire time.Time) string {
+		e := extra.NewExtraV2(custodian, v1.Payee, v1.NodeID, networkID(), expire, [16]byte{7})
+		for role, k := range map[string]string{
+			extra.RoleSigner:    "ed4c90d8a0a34e4a3e564ea1ee5399a14a920a8cc2fdc56be3e5fba88c44350e",
+			extra.RolePayee:     "f38222cdd1c17bbf748afa4b74c829785b4af24ea3b5b2172db04f413adc260c",
+			extra.RoleCustodian: "bdfe0792f1d613d7842587e6bce8a05e549876b5a840c47a0577b0540864ba0e",
+		} {
+			key, _ := crypto.KeyFromString(k)
+			assert.Nil(e.Sign(role, key))
+		}
+		return e.String()
+	}
+
+	hash := "5e7f37fd76bea1647d46c396e21c6496f3033f03ea50121500c6e6c2df5294b7"
+	now := time.Now()
+	_, err = session.Database(ctx).Query(ctx, "INSERT INTO nodes (custodian,payee,kernel_id,mixin_hash,keystore,public_key,created_at,updated_at) VALUES (?,?,?,?,?,?,?,?)",
+		v1.Custodian.String(), v1.Payee.String(), v1.NodeID.String(), hash, "", "", now, now)
+	assert.Nil(err)
+	_, err = ImportAppSeats(ctx, []*config.App{{
+		AppID:      "f857e241-9f04-4c55-b3ca-48bfda6675df",
+		SessionID:  "3a6eabab-0be3-4995-87e0-1cc7aa836b6d",
+		PrivateKey: "private",
+		PinToken:   "token",
+		Pin:        "123456",
+	}})
+	assert.Nil(err)
+
+	kernel.PutTransaction(&externals.Transaction{
+		Hash:  hash,
+		Extra: hex.EncodeToString(bot.EncodeMixinExtra(uuid.Nil.String(), build(v1.Payee, time.Now().Add(time.Hour)))),
+	})
+	transfer := &bot.TransferView{SnapshotId: uuid.Must(uuid.NewV4()).String(), AssetId: config.AppConfig.Governance.FeeAssetID, Amount: "100", Memo: hash}
+	_, err = PaymentNode(ctx, transfer)
+	serr, ok := err.(*session.Error)
+	assert.True(ok)
+	assert.Equal(10002, serr.Code)
+
+	kernel.PutTransaction(&externals.Transaction{
+		Hash:  hash,
+		Extra: hex.EncodeToString(bot.EncodeMixinExtra(uuid.Nil.String(), build(v1.Custodian, time.Now().Add(-time.Hour)))),
+	})
+	n, err := PaymentNode(ctx, transfer)
+	assert.Nil(err)
+	assert.Equal(NodeStateAppAssigned, n.State)
+}
+
+func TestInspectBundle(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	e, err := extra.DecodeString(buildTestExtra())
+	assert.Nil(err)
+	delete(e.Signatures, extra.RoleCustodian)
+	e.Signatures[extra.RolePayee][0] ^= 1
+	status, err := InspectBundle(ctx, extra.EncodeBundle(e))
+	assert.Nil(err)
+	assert.Equal([]string{extra.RoleSigner}, status.Signed)
+	assert.Equal([]string{extra.RolePayee}, status.Invalid)
+	assert.Equal([]string{extra.RoleCustodian}, status.Missing)
+
+	_, err = CreateNodeByBundle(ctx, extra.EncodeBundle(e))
+	assert.NotNil(err)
+}
+
+func TestNodeLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	key, custodian := testCustodianKey()
+	node, err := CreateNode(ctx, custodian, "payee", "kernel", "app", "hash")
+	assert.Nil(err)
+
+	sig := key.Sign(NodeStateSigningMessage(custodian, "app", NodeStateMigrated))
+	_, err = ConfirmNodeState(ctx, custodian, NodeStateMigrated, sig.String())
+	assert.NotNil(err)
+	sig = key.Sign(NodeStateSigningMessage(custodian, "other", NodeStateKeystoreDelivered))
+	_, err = ConfirmNodeState(ctx, custodian, NodeStateKeystoreDelivered, sig.String())
+	assert.NotNil(err)
+	sig = key.Sign(NodeStateSigningMessage(custodian, "app", NodeStateKeystoreDelivered))
+	_, err = ConfirmNodeState(ctx, custodian, NodeStateRevoked, sig.String())
+	assert.NotNil(err)
+	node, err = ConfirmNodeState(ctx, custodian, NodeStateKeystoreDelivered, sig.String())
+	assert.Nil(err)
+	assert.Equal(NodeStateKeystoreDelivered, node.State)
+	sig = key.Sign(NodeStateSigningMessage(custodian, "app", NodeStateMigrated))
+	node, err = ConfirmNodeState(ctx, custodian, NodeStateMigrated, sig.String())
+	assert.Nil(err)
+	assert.Equal(NodeStateMigrated, node.State)
+	node, err = UpdateNodeState(ctx, custodian, NodeStateRevoked)
+	assert.Nil(err)
+	assert.Equal(NodeStateRevoked, node.State)
+
+	t0 := time.Now()
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		n := &Node{Custodian: "registered", Payee: "registered", KernelID: "registered", State: NodeStateRegistered, CreatedAt: t0, UpdatedAt: t0}
+		_, err := tx.ExecContext(ctx, store.BuildInsertionSQL("nodes", nodesColumns), n.values()...)
+		return err
+	})
+	assert.Nil(err)
+	nodes, err := ExpireNodes(ctx, t0.Add(time.Hour))
+	assert.Nil(err)
+	assert.Len(nodes, 0)
+	nodes, err = ExpireNodes(ctx, t0.Add(NodeRegistrationTimeout+time.Second))
+	assert.Nil(err)
+	assert.Len(nodes, 1)
+	assert.Equal(NodeStateExpired, nodes[0].State)
+	_, err = UpdateNodeState(ctx, "registered", NodeStatePaid)
+	assert.NotNil(err)
+}
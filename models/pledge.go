@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+// BuildPledge collects all app assigned nodes into the custodian pledge of the
+// genesis Safe node set, the nodes are returned in the pledge order.
+func BuildPledge(ctx context.Context) (*extra.Pledge, []*Node, error) {
+	nodes, err := readAllNodesByState(ctx, NodeStateAppAssigned)
+	if err != nil {
+		return nil, nil, err
+	}
+	set := make(map[crypto.Hash]*Node, len(nodes))
+	pledge := &extra.Pledge{NetworkID: networkID()}
+	for _, n := range nodes {
+		custodian, err := common.NewAddressFromString(n.Custodian)
+		if err != nil {
+			return nil, nil, session.BadDataErrorWithFieldAndData(ctx, "custodian", "invalid", n.Custodian)
+		}
+		payee, err := common.NewAddressFromString(n.Payee)
+		if err != nil {
+			return nil, nil, session.BadDataErrorWithFieldAndData(ctx, "payee", "invalid", n.Payee)
+		}
+		id, err := crypto.HashFromString(n.KernelID)
+		if err != nil {
+			return nil, nil, session.BadDataErrorWithFieldAndData(ctx, "kernel_id", "invalid", n.KernelID)
+		}
+		set[id] = n
+		pledge.Nodes = append(pledge.Nodes, &extra.PledgeNode{Custodian: custodian, Payee: payee, NodeID: id})
+	}
+
+	decoded, err := extra.DecodePledge(pledge.Encode())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(decoded.Nodes) != len(nodes) {
+		return nil, nil, session.ServerError(ctx, fmt.Errorf("pledge includes %d of %d nodes", len(decoded.Nodes), len(nodes)))
+	}
+	pledge = decoded
+	included := make([]*Node, len(pledge.Nodes))
+	for i, pn := range pledge.Nodes {
+		included[i] = set[pn.NodeID]
+	}
+	return pledge, included, nil
+}
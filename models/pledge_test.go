@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPledge(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	kernels := []string{
+		"394e7b2131b7d0a996bb094e30d05ac7d51f5a09156e5f7349cac55d2179a144",
+		"2b0636403194b897a2d92d54060dd84acab78139626db2d919ce9ca84d64a433",
+		"cb5cd1a02f94ca98c060769e7c98f62cd14559b62d10762465ae46a51b69a432",
+	}
+	for i, k := range kernels {
+		_, custodian := testCustodianKey()
+		_, payee := testCustodianKey()
+		_, err := CreateNode(ctx, custodian, payee, k, k, k)
+		assert.Nil(err)
+		if i == 2 {
+			_, err = UpdateNodeState(ctx, custodian, NodeStateKeystoreDelivered)
+			assert.Nil(err)
+		}
+	}
+
+	pledge, nodes, err := BuildPledge(ctx)
+	assert.Nil(err)
+	assert.Len(nodes, 2)
+	assert.Equal(kernels[1], nodes[0].KernelID)
+	assert.Equal(kernels[0], nodes[1].KernelID)
+	decoded, err := extra.DecodePledge(pledge.Encode())
+	assert.Nil(err)
+	assert.Equal(pledge.Hash(), decoded.Hash())
+	assert.Equal(networkID(), pledge.NetworkID)
+
+	for i := 0; i < 120; i++ {
+		_, custodian := testCustodianKey()
+		_, payee := testCustodianKey()
+		k := crypto.NewHash([]byte(fmt.Sprint(i))).String()
+		_, err := CreateNode(ctx, custodian, payee, k, k, k)
+		assert.Nil(err)
+	}
+	pledge, nodes, err = BuildPledge(ctx)
+	assert.Nil(err)
+	assert.Len(nodes, 122)
+	assert.Len(pledge.Nodes, 122)
+}
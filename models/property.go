@@ -0,0 +1,35 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+func ReadProperty(ctx context.Context, key string) (string, error) {
+	var value string
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT value FROM properties WHERE key=?", key).Scan(&value)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return "", session.TransactionError(ctx, err)
+	}
+	return value, nil
+}
+
+func WriteProperty(ctx context.Context, key, value string) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO properties (key,value,updated_at) VALUES (?,?,?) ON CONFLICT (key) DO UPDATE SET value=excluded.value,updated_at=excluded.updated_at", key, value, time.Now())
+		return err
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	RefundStatePending = "pending"
+	RefundStateSent    = "sent"
+
+	RefundReasonInvalidAsset       = "invalid_asset"
+	RefundReasonInvalidAmount      = "invalid_amount"
+	RefundReasonEmptyMemo          = "empty_memo"
+	RefundReasonInvalidPayment     = "invalid_payment"
+	RefundReasonUnmatchedPayment   = "unmatched_payment"
+	RefundReasonDuplicatePayment   = "duplicate_payment"
+	RefundReasonNoSeatsLeft        = "no_seats_left"
+	RefundReasonRegistrationClosed = "registration_closed"
+	RefundReasonInvalidChallenge   = "invalid_challenge"
+	RefundReasonInactiveNode       = "inactive_node"
+)
+
+type Refund struct {
+	TraceID    string
+	SnapshotID string
+	OpponentID string
+	AssetID    string
+	Amount     string
+	Memo       string
+	Reason     string
+	State      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+var refundsColumns = []string{"trace_id", "snapshot_id", "opponent_id", "asset_id", "amount", "memo", "reason", "state", "created_at", "updated_at"}
+
+func (r *Refund) values() []any {
+	return []any{r.TraceID, r.SnapshotID, r.OpponentID, r.AssetID, r.Amount, r.Memo, r.Reason, r.State, r.CreatedAt, r.UpdatedAt}
+}
+
+func refundFromRow(row store.Row) (*Refund, error) {
+	var r Refund
+	err := row.Scan(&r.TraceID, &r.SnapshotID, &r.OpponentID, &r.AssetID, &r.Amount, &r.Memo, &r.Reason, &r.State, &r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &r, err
+}
+
+func RefundTraceID(snapshotID string) string {
+	return bot.UniqueObjectId("REFUND", snapshotID)
+}
+
+func CreateRefund(ctx context.Context, transfer *bot.TransferView, reason string) (*Refund, error) {
+	t := time.Now()
+	refund := &Refund{
+		TraceID:    RefundTraceID(transfer.SnapshotId),
+		SnapshotID: transfer.SnapshotId,
+		OpponentID: transfer.CounterUserId,
+		AssetID:    transfer.AssetId,
+		Amount:     transfer.Amount,
+		Memo:       fmt.Sprintf("REFUND:%s", reason),
+		Reason:     reason,
+		State:      RefundStatePending,
+		CreatedAt:  t,
+		UpdatedAt:  t,
+	}
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM refunds WHERE trace_id=?", strings.Join(refundsColumns, ","))
+		old, err := refundFromRow(tx.QueryRowContext(ctx, query, refund.TraceID))
+		if err != nil {
+			return err
+		} else if old != nil {
+			refund = old
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("refunds", refundsColumns), refund.values()...)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return refund, nil
+}
+
+func ReadRefunds(ctx context.Context, state string, limit int) ([]*Refund, error) {
+	query := fmt.Sprintf("SELECT %s FROM refunds ORDER BY created_at DESC LIMIT %d", strings.Join(refundsColumns, ","), limit)
+	var args []any
+	if state != "" {
+		query = fmt.Sprintf("SELECT %s FROM refunds WHERE state=? ORDER BY created_at LIMIT %d", strings.Join(refundsColumns, ","), limit)
+		args = append(args, state)
+	}
+	var refunds []*Refund
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			refund, err := refundFromRow(rows)
+			if err != nil {
+				return err
+			}
+			refunds = append(refunds, refund)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return refunds, nil
+}
+
+func UpdateRefundSent(ctx context.Context, traceID string) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE refunds SET state=?,updated_at=? WHERE trace_id=?", RefundStateSent, time.Now(), traceID)
+		return err
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/MixinNetwork/go-number"
+	"github.com/MixinNetwork/safe/governance/session"
+)
+
+const (
+	RewardCustodianShare = "0.4"
+
+	rewardLivenessWeight          = 10
+	rewardChallengeAnsweredWeight = 10
+	rewardChallengeMissedPenalty  = 10
+	rewardPrecision               = 8
+)
+
+type RewardMetrics struct {
+	Custodian          string
+	Payee              string
+	KernelSignatures   int64
+	SafeSignatures     int64
+	ChallengesAnswered int64
+	ChallengesMissed   int64
+}
+
+type RewardPayout struct {
+	*RewardMetrics
+	Weight int64
+	Amount string
+}
+
+type RewardTable struct {
+	Day            time.Time
+	Mint           string
+	CustodianShare string
+	TotalWeight    int64
+	Payouts        []*RewardPayout
+}
+
+// Weight is the Safe work contribution of the node in a day, it is zero unless
+// the node produced both kernel and safe signatures. Liveness counts once per
+// day no matter how many signatures were recorded, so only challenges can add
+// more weight.
+func (m *RewardMetrics) Weight() int64 {
+	if m.KernelSignatures == 0 || m.SafeSignatures == 0 {
+		return 0
+	}
+	w := int64(rewardLivenessWeight)
+	w += m.ChallengesAnswered * rewardChallengeAnsweredWeight
+	w -= m.ChallengesMissed * rewardChallengeMissedPenalty
+	if w < 0 {
+		return 0
+	}
+	return w
+}
+
+// CalculateRewards splits the custodian share of the mint by weight, amounts
+// are floored to 8 decimals and the remainder goes to the heaviest payee, the
+// table is sorted by payee so the same input always produces the same output.
+func CalculateRewards(day time.Time, mint string, metrics []*RewardMetrics) *RewardTable {
+	share := number.FromString(mint).Mul(number.FromString(RewardCustodianShare)).RoundFloor(rewardPrecision)
+	table := &RewardTable{
+		Day:            day,
+		Mint:           number.FromString(mint).Persist(),
+		CustodianShare: share.Persist(),
+	}
+	for _, m := range metrics {
+		p := &RewardPayout{RewardMetrics: m, Weight: m.Weight(), Amount: "0"}
+		table.TotalWeight += p.Weight
+		table.Payouts = append(table.Payouts, p)
+	}
+	sort.Slice(table.Payouts, func(i, j int) bool {
+		return table.Payouts[i].Payee < table.Payouts[j].Payee
+	})
+	if table.TotalWeight == 0 || share.Sign() <= 0 {
+		return table
+	}
+
+	var heaviest *RewardPayout
+	total, distributed := number.NewDecimal(table.TotalWeight, 0), number.Zero()
+	for _, p := range table.Payouts {
+		if p.Weight == 0 {
+			continue
+		}
+		amount := share.Mul(number.NewDecimal(p.Weight, 0)).Div(total).RoundFloor(rewardPrecision)
+		p.Amount = amount.Persist()
+		distributed = distributed.Add(amount)
+		if heaviest == nil || p.Weight > heaviest.Weight {
+			heaviest = p
+		}
+	}
+	remainder := share.Sub(distributed)
+	heaviest.Amount = number.FromString(heaviest.Amount).Add(remainder).Persist()
+	return table
+}
+
+func BuildRewardTable(ctx context.Context, day time.Time, mint string) (*RewardTable, error) {
+	if number.FromString(mint).Sign() <= 0 {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "mint", "invalid", mint)
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	metrics, err := ReadRewardMetrics(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	return CalculateRewards(day, mint, metrics), nil
+}
+
+// ReadRewardMetrics collects the contribution of every node with liveness
+// or challenge evidence recorded in the UTC day starting at day. The nodes are
+// chosen by the evidence instead of their current state, so the table of a
+// past day never changes.
+func ReadRewardMetrics(ctx context.Context, day time.Time) ([]*RewardMetrics, error) {
+	start, end := day, day.AddDate(0, 0, 1)
+	metrics := make(map[string]*RewardMetrics)
+	metric := func(custodian string) *RewardMetrics {
+		m := metrics[custodian]
+		if m == nil {
+			m = &RewardMetrics{Custodian: custodian}
+			metrics[custodian] = m
+		}
+		return m
+	}
+	var list []*RewardMetrics
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := "SELECT custodian,kind,signed_at FROM liveness_signatures WHERE week=?"
+		err := scanRewardTimes(ctx, tx, query, []any{LivenessWeekName(start)}, start, end, func(custodian, kind string) {
+			if kind == LivenessKindKernel {
+				metric(custodian).KernelSignatures += 1
+			} else if kind == LivenessKindSafe {
+				metric(custodian).SafeSignatures += 1
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		query = "SELECT custodian,state,updated_at FROM challenges WHERE state IN (?,?)"
+		err = scanRewardTimes(ctx, tx, query, []any{ChallengeStateAnswered, ChallengeStateExpired}, start, end, func(custodian, state string) {
+			if state == ChallengeStateAnswered {
+				metric(custodian).ChallengesAnswered += 1
+			} else if state == ChallengeStateExpired {
+				metric(custodian).ChallengesMissed += 1
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, m := range metrics {
+			err = tx.QueryRowContext(ctx, "SELECT payee FROM nodes WHERE custodian=?", m.Custodian).Scan(&m.Payee)
+			if err == sql.ErrNoRows {
+				continue
+			} else if err != nil {
+				return err
+			}
+			list = append(list, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return list, nil
+}
+
+// scanRewardTimes calls f for every row in [start, end), the times are stored
+// as text and may be written in any zone, so they are compared here instead of
+// in the query.
+func scanRewardTimes(ctx context.Context, tx *sql.Tx, query string, args []any, start, end time.Time, f func(string, string)) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var custodian, key string
+		var t sql.NullTime
+		err = rows.Scan(&custodian, &key, &t)
+		if err != nil {
+			return err
+		}
+		if t.Valid && !t.Time.Before(start) && t.Time.Before(end) {
+			f(custodian, key)
+		}
+	}
+	return rows.Err()
+}
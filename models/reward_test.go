@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateRewards(t *testing.T) {
+	assert := assert.New(t)
+
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	metrics := []*RewardMetrics{
+		{Custodian: "c", Payee: "XINc", KernelSignatures: 3, SafeSignatures: 2},
+		{Custodian: "a", Payee: "XINa", KernelSignatures: 1, SafeSignatures: 1, ChallengesAnswered: 1},
+		{Custodian: "b", Payee: "XINb", KernelSignatures: 5, SafeSignatures: 0},
+		{Custodian: "d", Payee: "XINd", KernelSignatures: 1, SafeSignatures: 1, ChallengesMissed: 1},
+	}
+	table := CalculateRewards(day, "100", metrics)
+	assert.Equal("40", table.CustodianShare)
+	assert.Equal(int64(30), table.TotalWeight)
+	assert.Len(table.Payouts, 4)
+	assert.Equal("XINa", table.Payouts[0].Payee)
+	assert.Equal("26.66666667", table.Payouts[0].Amount)
+	assert.Equal("0", table.Payouts[1].Amount)
+	assert.Equal("13.33333333", table.Payouts[2].Amount)
+	assert.Equal("0", table.Payouts[3].Amount)
+
+	again := CalculateRewards(day, "100", []*RewardMetrics{metrics[3], metrics[2], metrics[1], metrics[0]})
+	for i, p := range again.Payouts {
+		assert.Equal(table.Payouts[i].Payee, p.Payee)
+		assert.Equal(table.Payouts[i].Amount, p.Amount)
+	}
+}
+
+func TestBuildRewardTable(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	_, err := CreateNode(ctx, "custodian", "payee", "kernel", "app", "hash")
+	assert.Nil(err)
+	_, err = CreateNode(ctx, "idle", "idle-payee", "idle-kernel", "idle-app", "idle-hash")
+	assert.Nil(err)
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	_, err = RecordLivenessSignature(ctx, "custodian", LivenessKindKernel, "kernel", day.Add(time.Hour))
+	assert.Nil(err)
+	_, err = RecordLivenessSignature(ctx, "custodian", LivenessKindSafe, "safe", day.Add(2*time.Hour))
+	assert.Nil(err)
+	_, err = RecordLivenessSignature(ctx, "custodian", LivenessKindSafe, "later", day.Add(25*time.Hour))
+	assert.Nil(err)
+	zone := time.FixedZone("EAT", 3*3600)
+	_, err = RecordLivenessSignature(ctx, "custodian", LivenessKindKernel, "earlier", day.Add(-time.Hour).In(zone))
+	assert.Nil(err)
+
+	_, err = BuildRewardTable(ctx, day, "0")
+	assert.NotNil(err)
+	table, err := BuildRewardTable(ctx, day, "10")
+	assert.Nil(err)
+	assert.Len(table.Payouts, 1)
+	assert.Equal(int64(1), table.Payouts[0].KernelSignatures)
+	assert.Equal(int64(1), table.Payouts[0].SafeSignatures)
+	assert.Equal("4", table.Payouts[0].Amount)
+
+	_, err = UpdateNodeState(ctx, "custodian", NodeStateRevoked)
+	assert.Nil(err)
+	again, err := BuildRewardTable(ctx, day, "10")
+	assert.Nil(err)
+	assert.Len(again.Payouts, 1)
+	assert.Equal("payee", again.Payouts[0].Payee)
+	assert.Equal("4", again.Payouts[0].Amount)
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const seatKDFInfo = "MIXIN SAFE GOVERNANCE APP SEAT V1"
+
+type AppSeat struct {
+	AppID       string
+	Position    int
+	Custodian   sql.NullString
+	ReservedAt  sql.NullTime
+	Credentials string
+	RetiredAt   sql.NullTime
+	CreatedAt   time.Time
+}
+
+var appSeatsColumns = []string{"app_id", "position", "custodian", "reserved_at", "credentials", "retired_at", "created_at"}
+
+func (s *AppSeat) values() []any {
+	return []any{s.AppID, s.Position, s.Custodian, s.ReservedAt, s.Credentials, s.RetiredAt, s.CreatedAt}
+}
+
+func appSeatFromRow(row store.Row) (*AppSeat, error) {
+	var s AppSeat
+	err := row.Scan(&s.AppID, &s.Position, &s.Custodian, &s.ReservedAt, &s.Credentials, &s.RetiredAt, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &s, err
+}
+
+func (s *AppSeat) App() (*config.App, error) {
+	key, err := seatKey()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(s.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := openEnvelope(key, seatKDFInfo, sealed)
+	if err != nil {
+		return nil, err
+	}
+	var app config.App
+	err = json.Unmarshal(plain, &app)
+	if err != nil {
+		return nil, err
+	}
+	if app.AppID != s.AppID {
+		return nil, fmt.Errorf("app seat %s credentials mismatch %s", s.AppID, app.AppID)
+	}
+	return &app, nil
+}
+
+func seatKey() ([]byte, error) {
+	key, err := hex.DecodeString(config.AppConfig.Governance.SeatKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid governance seat key")
+	}
+	return key, nil
+}
+
+// ImportAppSeats seals the credentials of each app with the operator seat key.
+// New apps are appended after the existing positions, and the credentials of
+// existing seats are replaced.
+func ImportAppSeats(ctx context.Context, apps []*config.App) ([]*AppSeat, error) {
+	key, err := seatKey()
+	if err != nil {
+		return nil, err
+	}
+	var seats []*AppSeat
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM app_seats").Scan(&count)
+		if err != nil {
+			return err
+		}
+		for _, app := range apps {
+			if app.AppID == "" || app.SessionID == "" || app.PrivateKey == "" {
+				return fmt.Errorf("invalid app %s", app.AppID)
+			}
+			plain, err := json.Marshal(app)
+			if err != nil {
+				return err
+			}
+			sealed, err := sealEnvelope(key, seatKDFInfo, plain)
+			if err != nil {
+				return err
+			}
+			credentials := base64.RawURLEncoding.EncodeToString(sealed)
+
+			query := fmt.Sprintf("SELECT %s FROM app_seats WHERE app_id=?", strings.Join(appSeatsColumns, ","))
+			seat, err := appSeatFromRow(tx.QueryRowContext(ctx, query, app.AppID))
+			if err != nil {
+				return err
+			} else if seat != nil {
+				seat.Credentials = credentials
+				_, err = tx.ExecContext(ctx, "UPDATE app_seats SET credentials=? WHERE app_id=?", seat.Credentials, seat.AppID)
+				if err != nil {
+					return err
+				}
+				seats = append(seats, seat)
+				continue
+			}
+			seat = &AppSeat{
+				AppID:       app.AppID,
+				Position:    count,
+				Credentials: credentials,
+				CreatedAt:   time.Now(),
+			}
+			_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("app_seats", appSeatsColumns), seat.values()...)
+			if err != nil {
+				return err
+			}
+			seats = append(seats, seat)
+			count = count + 1
+		}
+		return syncAppSeats(ctx, tx)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return seats, nil
+}
+
+// SyncAppSeats marks the seats already taken by registered nodes.
+func SyncAppSeats(ctx context.Context) error {
+	err := session.Database(ctx).RunInTransaction(ctx, syncAppSeats)
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+func syncAppSeats(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "UPDATE app_seats SET custodian=(SELECT custodian FROM nodes WHERE nodes.app_id=app_seats.app_id),reserved_at=? WHERE custodian IS NULL AND app_id IN (SELECT app_id FROM nodes WHERE app_id IS NOT NULL)", time.Now())
+	return err
+}
+
+func RetireAppSeat(ctx context.Context, appID string) (*AppSeat, error) {
+	var seat *AppSeat
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM app_seats WHERE app_id=?", strings.Join(appSeatsColumns, ","))
+		old, err := appSeatFromRow(tx.QueryRowContext(ctx, query, appID))
+		if err != nil {
+			return err
+		} else if old == nil {
+			return session.NotFoundError(ctx)
+		} else if old.Custodian.Valid {
+			return session.BadDataErrorWithFieldAndData(ctx, "app id", "reserved", appID)
+		}
+		seat = old
+		if seat.RetiredAt.Valid {
+			return nil
+		}
+		seat.RetiredAt = sql.NullTime{Time: time.Now(), Valid: true}
+		_, err = tx.ExecContext(ctx, "UPDATE app_seats SET retired_at=? WHERE app_id=?", seat.RetiredAt, seat.AppID)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return seat, nil
+}
+
+func ReadAppSeats(ctx context.Context) ([]*AppSeat, error) {
+	var seats []*AppSeat
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM app_seats ORDER BY position", strings.Join(appSeatsColumns, ","))
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			seat, err := appSeatFromRow(rows)
+			if err != nil {
+				return err
+			}
+			seats = append(seats, seat)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return seats, nil
+}
+
+func reserveAppSeat(ctx context.Context, tx *sql.Tx, custodian string) (*AppSeat, error) {
+	query := fmt.Sprintf("SELECT %s FROM app_seats WHERE custodian=?", strings.Join(appSeatsColumns, ","))
+	seat, err := appSeatFromRow(tx.QueryRowContext(ctx, query, custodian))
+	if err != nil || seat != nil {
+		return seat, err
+	}
+	query = fmt.Sprintf("SELECT %s FROM app_seats WHERE custodian IS NULL AND retired_at IS NULL AND credentials!='' ORDER BY position LIMIT 1", strings.Join(appSeatsColumns, ","))
+	seat, err = appSeatFromRow(tx.QueryRowContext(ctx, query))
+	if err != nil {
+		return nil, err
+	} else if seat == nil {
+		return nil, session.NoSeatsLeftError(ctx)
+	}
+	seat.Custodian = sql.NullString{String: custodian, Valid: true}
+	seat.ReservedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	_, err = tx.ExecContext(ctx, "UPDATE app_seats SET custodian=?,reserved_at=? WHERE app_id=? AND custodian IS NULL", seat.Custodian, seat.ReservedAt, seat.AppID)
+	return seat, err
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppSeats(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	_, err := CreateNode(ctx, "custodian-taken", "payee", "kernel", "app-2", "hash")
+	assert.Nil(err)
+	var apps []*config.App
+	for _, id := range []string{"app-1", "app-2", "app-3", "app-4", "app-5"} {
+		apps = append(apps, &config.App{AppID: id, SessionID: "session", PrivateKey: "private-" + id})
+	}
+	_, err = ImportAppSeats(ctx, apps[:3])
+	assert.Nil(err)
+	seats, err := ImportAppSeats(ctx, apps[2:])
+	assert.Nil(err)
+	assert.Len(seats, 3)
+	_, err = ImportAppSeats(ctx, []*config.App{{AppID: "app-6"}})
+	assert.NotNil(err)
+
+	seats, err = ReadAppSeats(ctx)
+	assert.Nil(err)
+	assert.Len(seats, 5)
+	assert.Equal("app-5", seats[4].AppID)
+	assert.Equal(4, seats[4].Position)
+	assert.Equal("custodian-taken", seats[1].Custodian.String)
+	assert.NotContains(seats[0].Credentials, "private-app-1")
+	app, err := seats[0].App()
+	assert.Nil(err)
+	assert.Equal("private-app-1", app.PrivateKey)
+
+	_, err = RetireAppSeat(ctx, "app-2")
+	assert.NotNil(err)
+	seat, err := RetireAppSeat(ctx, "app-3")
+	assert.Nil(err)
+	assert.True(seat.RetiredAt.Valid)
+
+	reserve := func(custodian string) (*AppSeat, error) {
+		var seat *AppSeat
+		err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			s, err := reserveAppSeat(ctx, tx, custodian)
+			seat = s
+			return err
+		})
+		return seat, err
+	}
+	seat, err = reserve("custodian-1")
+	assert.Nil(err)
+	assert.Equal("app-1", seat.AppID)
+	seat, err = reserve("custodian-1")
+	assert.Nil(err)
+	assert.Equal("app-1", seat.AppID)
+	seat, err = reserve("custodian-2")
+	assert.Nil(err)
+	assert.Equal("app-4", seat.AppID)
+	seat, err = reserve("custodian-3")
+	assert.Nil(err)
+	assert.Equal("app-5", seat.AppID)
+	_, err = reserve("custodian-4")
+	serr, ok := err.(*session.Error)
+	assert.True(ok)
+	assert.Equal(10005, serr.Code)
+}
@@ -0,0 +1,410 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/config"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const (
+	EvidenceTypeMissingTransaction  = "missing_transaction_signature"
+	EvidenceTypeMissingVerification = "missing_verification_signature"
+	EvidenceTypeMissedLiveness      = "missed_liveness"
+	EvidenceTypeChallengeAnswered   = "challenge_answered"
+	EvidenceTypeChallengeMissed     = "challenge_missed"
+
+	SlashProposalStatePending = "pending"
+	SlashProposalStateFinal   = "final"
+
+	slashEvidenceDomain = "SAFE-GOVERNANCE-SLASH-EVIDENCE"
+	slashApprovalDomain = "SAFE-GOVERNANCE-SLASH-APPROVAL"
+)
+
+var EvidenceTypes = []string{EvidenceTypeMissingTransaction, EvidenceTypeMissingVerification, EvidenceTypeMissedLiveness}
+
+type Evidence struct {
+	EvidenceID string
+	Type       string
+	Custodian  string
+	Reporter   string
+	Payload    string
+	Signature  string
+	Verified   bool
+	ProposalID sql.NullString
+	CreatedAt  time.Time
+}
+
+type SlashProposal struct {
+	ProposalID string
+	Custodian  string
+	State      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Approvals []*SlashApproval
+	Threshold int
+}
+
+type SlashApproval struct {
+	ProposalID string
+	Approver   string
+	Signature  string
+	CreatedAt  time.Time
+}
+
+var evidencesColumns = []string{"evidence_id", "type", "custodian", "reporter", "payload", "signature", "verified", "proposal_id", "created_at"}
+var slashProposalsColumns = []string{"proposal_id", "custodian", "state", "created_at", "updated_at"}
+var slashApprovalsColumns = []string{"proposal_id", "approver", "signature", "created_at"}
+
+func (e *Evidence) values() []any {
+	return []any{e.EvidenceID, e.Type, e.Custodian, e.Reporter, e.Payload, e.Signature, e.Verified, e.ProposalID, e.CreatedAt}
+}
+
+func (p *SlashProposal) values() []any {
+	return []any{p.ProposalID, p.Custodian, p.State, p.CreatedAt, p.UpdatedAt}
+}
+
+func (a *SlashApproval) values() []any {
+	return []any{a.ProposalID, a.Approver, a.Signature, a.CreatedAt}
+}
+
+func evidenceFromRow(row store.Row) (*Evidence, error) {
+	var e Evidence
+	err := row.Scan(&e.EvidenceID, &e.Type, &e.Custodian, &e.Reporter, &e.Payload, &e.Signature, &e.Verified, &e.ProposalID, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &e, err
+}
+
+func slashProposalFromRow(row store.Row) (*SlashProposal, error) {
+	var p SlashProposal
+	err := row.Scan(&p.ProposalID, &p.Custodian, &p.State, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
+}
+
+func slashApprovalFromRow(row store.Row) (*SlashApproval, error) {
+	var a SlashApproval
+	err := row.Scan(&a.ProposalID, &a.Approver, &a.Signature, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &a, err
+}
+
+func EvidenceSigningMessage(typ, custodian, payload string) []byte {
+	msg := crypto.NewHash([]byte(slashEvidenceDomain + typ + custodian + payload))
+	return msg[:]
+}
+
+func SlashApprovalSigningMessage(proposalID string) []byte {
+	msg := crypto.NewHash([]byte(slashApprovalDomain + proposalID))
+	return msg[:]
+}
+
+// CreateEvidence records the evidence signed by the reporter custodian key.
+// It is verified only if the reporter is an active Safe node and the payload
+// can be checked, and verified evidences are added to the pending proposal.
+func CreateEvidence(ctx context.Context, typ, custodian, reporter, payload, signature string) (*Evidence, error) {
+	if !validEvidenceType(typ) || payload == "" || custodian == reporter {
+		return nil, session.BadDataError(ctx)
+	}
+	if !verifyCustodianSignature(reporter, signature, EvidenceSigningMessage(typ, custodian, payload)) {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+	}
+	node, err := ReadNode(ctx, custodian)
+	if err != nil {
+		return nil, err
+	} else if node == nil {
+		return nil, session.NotFoundError(ctx)
+	}
+	verified, err := checkActiveNode(ctx, reporter)
+	if err != nil {
+		return nil, err
+	}
+	if verified && typ == EvidenceTypeMissedLiveness {
+		verified, err = checkMissedLiveness(ctx, node, payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	evidence := &Evidence{
+		EvidenceID: bot.UniqueObjectId(slashEvidenceDomain, typ, custodian, reporter, payload),
+		Type:       typ,
+		Custodian:  custodian,
+		Reporter:   reporter,
+		Payload:    payload,
+		Signature:  signature,
+		Verified:   verified,
+		CreatedAt:  time.Now(),
+	}
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		old, err := findEvidence(ctx, tx, evidence.EvidenceID)
+		if err != nil || old != nil {
+			evidence = old
+			return err
+		}
+		return writeEvidence(ctx, tx, evidence, evidence.Verified)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return evidence, nil
+}
+
+// writeEvidence inserts the evidence, and attaches it to the pending slash
+// proposal of the custodian if slashable.
+func writeEvidence(ctx context.Context, tx *sql.Tx, evidence *Evidence, slashable bool) error {
+	if slashable {
+		proposal, err := findPendingSlashProposal(ctx, tx, evidence.Custodian)
+		if err != nil {
+			return err
+		}
+		if proposal == nil {
+			proposal = &SlashProposal{
+				ProposalID: bot.UniqueObjectId(slashApprovalDomain, evidence.EvidenceID),
+				Custodian:  evidence.Custodian,
+				State:      SlashProposalStatePending,
+				CreatedAt:  evidence.CreatedAt,
+				UpdatedAt:  evidence.CreatedAt,
+			}
+			_, err = tx.ExecContext(ctx, store.BuildInsertionSQL("slash_proposals", slashProposalsColumns), proposal.values()...)
+			if err != nil {
+				return err
+			}
+		}
+		evidence.ProposalID = sql.NullString{String: proposal.ProposalID, Valid: true}
+	}
+	query := store.BuildInsertionSQL("evidences", evidencesColumns) + " ON CONFLICT (evidence_id) DO NOTHING"
+	_, err := tx.ExecContext(ctx, query, evidence.values()...)
+	return err
+}
+
+func ReadEvidences(ctx context.Context, custodian string) ([]*Evidence, error) {
+	query := fmt.Sprintf("SELECT %s FROM evidences ORDER BY created_at", strings.Join(evidencesColumns, ","))
+	var args []any
+	if custodian != "" {
+		query = fmt.Sprintf("SELECT %s FROM evidences WHERE custodian=? ORDER BY created_at", strings.Join(evidencesColumns, ","))
+		args = append(args, custodian)
+	}
+	var evidences []*Evidence
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			e, err := evidenceFromRow(rows)
+			if err != nil {
+				return err
+			}
+			evidences = append(evidences, e)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return evidences, nil
+}
+
+func ReadSlashProposals(ctx context.Context) ([]*SlashProposal, error) {
+	var proposals []*SlashProposal
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM slash_proposals ORDER BY created_at", strings.Join(slashProposalsColumns, ","))
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			p, err := slashProposalFromRow(rows)
+			if err != nil {
+				return err
+			}
+			proposals = append(proposals, p)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, p := range proposals {
+			err = p.loadApprovals(ctx, tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return proposals, nil
+}
+
+func ReadSlashProposal(ctx context.Context, id string) (*SlashProposal, error) {
+	var proposal *SlashProposal
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		p, err := findSlashProposal(ctx, tx, id)
+		if err != nil || p == nil {
+			return err
+		}
+		proposal = p
+		return p.loadApprovals(ctx, tx)
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return proposal, nil
+}
+
+// ApproveSlashProposal adds the approval signed by an active Safe node other
+// than the accused one, and finalizes the proposal once the quorum is reached.
+func ApproveSlashProposal(ctx context.Context, id, approver, signature string) (*SlashProposal, error) {
+	if !verifyCustodianSignature(approver, signature, SlashApprovalSigningMessage(id)) {
+		return nil, session.BadDataErrorWithFieldAndData(ctx, "signature", "invalid", signature)
+	}
+	active, err := checkActiveNode(ctx, approver)
+	if err != nil {
+		return nil, err
+	} else if !active {
+		return nil, session.ForbiddenError(ctx)
+	}
+
+	var proposal *SlashProposal
+	err = session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		p, err := findSlashProposal(ctx, tx, id)
+		if err != nil {
+			return err
+		} else if p == nil {
+			return session.NotFoundError(ctx)
+		} else if p.Custodian == approver {
+			return session.ForbiddenError(ctx)
+		}
+		proposal = p
+		if p.State != SlashProposalStatePending {
+			return p.loadApprovals(ctx, tx)
+		}
+
+		approval := &SlashApproval{ProposalID: p.ProposalID, Approver: approver, Signature: signature, CreatedAt: time.Now()}
+		query := store.BuildInsertionSQL("slash_approvals", slashApprovalsColumns) + " ON CONFLICT (proposal_id, approver) DO NOTHING"
+		_, err = tx.ExecContext(ctx, query, approval.values()...)
+		if err != nil {
+			return err
+		}
+		err = p.loadApprovals(ctx, tx)
+		if err != nil || len(p.Approvals) < p.Threshold {
+			return err
+		}
+		p.State, p.UpdatedAt = SlashProposalStateFinal, approval.CreatedAt
+		_, err = tx.ExecContext(ctx, "UPDATE slash_proposals SET state=?,updated_at=? WHERE proposal_id=?", p.State, p.UpdatedAt, p.ProposalID)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return proposal, nil
+}
+
+func (p *SlashProposal) loadApprovals(ctx context.Context, tx *sql.Tx) error {
+	query := fmt.Sprintf("SELECT %s FROM slash_approvals WHERE proposal_id=? ORDER BY created_at", strings.Join(slashApprovalsColumns, ","))
+	rows, err := tx.QueryContext(ctx, query, p.ProposalID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	p.Approvals = nil
+	for rows.Next() {
+		a, err := slashApprovalFromRow(rows)
+		if err != nil {
+			return err
+		}
+		p.Approvals = append(p.Approvals, a)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	var active int
+	query = fmt.Sprintf("SELECT COUNT(*) FROM nodes WHERE state IN ('%s') AND custodian!=?", strings.Join(activeNodeStates, "','"))
+	err = tx.QueryRowContext(ctx, query, p.Custodian).Scan(&active)
+	p.Threshold = config.AppConfig.SlashThreshold(active)
+	return err
+}
+
+func checkActiveNode(ctx context.Context, custodian string) (bool, error) {
+	node, err := ReadNode(ctx, custodian)
+	if err != nil || node == nil {
+		return false, err
+	}
+	for _, s := range activeNodeStates {
+		if node.State == s {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func checkMissedLiveness(ctx context.Context, node *Node, week string) (bool, error) {
+	weeks, err := ReadNodeLiveness(ctx, node, time.Now())
+	if err != nil {
+		return false, err
+	}
+	for _, w := range weeks {
+		if w.Week == week {
+			return w.Missed, nil
+		}
+	}
+	return false, nil
+}
+
+func verifyCustodianSignature(custodian, signature string, msg []byte) bool {
+	addr, err := common.NewAddressFromString(custodian)
+	if err != nil {
+		return false
+	}
+	buf, err := hex.DecodeString(signature)
+	if err != nil || len(buf) != len(crypto.Signature{}) {
+		return false
+	}
+	var sig crypto.Signature
+	copy(sig[:], buf)
+	return addr.PublicSpendKey.Verify(msg, sig)
+}
+
+func validEvidenceType(typ string) bool {
+	for _, t := range EvidenceTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func findEvidence(ctx context.Context, tx *sql.Tx, id string) (*Evidence, error) {
+	query := fmt.Sprintf("SELECT %s FROM evidences WHERE evidence_id=?", strings.Join(evidencesColumns, ","))
+	return evidenceFromRow(tx.QueryRowContext(ctx, query, id))
+}
+
+func findSlashProposal(ctx context.Context, tx *sql.Tx, id string) (*SlashProposal, error) {
+	query := fmt.Sprintf("SELECT %s FROM slash_proposals WHERE proposal_id=?", strings.Join(slashProposalsColumns, ","))
+	return slashProposalFromRow(tx.QueryRowContext(ctx, query, id))
+}
+
+func findPendingSlashProposal(ctx context.Context, tx *sql.Tx, custodian string) (*SlashProposal, error) {
+	query := fmt.Sprintf("SELECT %s FROM slash_proposals WHERE custodian=? AND state=? ORDER BY created_at LIMIT 1", strings.Join(slashProposalsColumns, ","))
+	return slashProposalFromRow(tx.QueryRowContext(ctx, query, custodian, SlashProposalStatePending))
+}
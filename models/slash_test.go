@@ -0,0 +1,90 @@
+package models
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlashProposal(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	keys := make([]crypto.Key, 5)
+	custodians := make([]string, 5)
+	for i := range keys {
+		keys[i], custodians[i] = testCustodianKey()
+		if i < 4 {
+			_, err := CreateNode(ctx, custodians[i], custodians[i], custodians[i], custodians[i], custodians[i])
+			assert.Nil(err)
+		}
+	}
+	accused := custodians[0]
+
+	payload := "5e7f37fd76bea1647d46c396e21c6496f3033f03ea50121500c6e6c2df5294b7"
+	sig := keys[1].Sign(EvidenceSigningMessage(EvidenceTypeMissingTransaction, accused, payload))
+	_, err := CreateEvidence(ctx, EvidenceTypeMissingTransaction, accused, custodians[1], payload, sig.String()[2:]+"00")
+	assert.NotNil(err)
+	_, err = CreateEvidence(ctx, "unknown", accused, custodians[1], payload, sig.String())
+	assert.NotNil(err)
+	evidence, err := CreateEvidence(ctx, EvidenceTypeMissingTransaction, accused, custodians[1], payload, sig.String())
+	assert.Nil(err)
+	assert.True(evidence.Verified)
+	assert.True(evidence.ProposalID.Valid)
+	same, err := CreateEvidence(ctx, EvidenceTypeMissingTransaction, accused, custodians[1], payload, sig.String())
+	assert.Nil(err)
+	assert.Equal(evidence.EvidenceID, same.EvidenceID)
+
+	sig = keys[4].Sign(EvidenceSigningMessage(EvidenceTypeMissingVerification, accused, payload))
+	outsider, err := CreateEvidence(ctx, EvidenceTypeMissingVerification, accused, custodians[4], payload, sig.String())
+	assert.Nil(err)
+	assert.False(outsider.Verified)
+	assert.False(outsider.ProposalID.Valid)
+	evidences, err := ReadEvidences(ctx, accused)
+	assert.Nil(err)
+	assert.Len(evidences, 2)
+
+	id := evidence.ProposalID.String
+	sig = keys[0].Sign(SlashApprovalSigningMessage(id))
+	_, err = ApproveSlashProposal(ctx, id, accused, sig.String())
+	serr, _ := err.(*session.Error)
+	assert.NotNil(serr)
+	assert.Equal(403, serr.Code)
+
+	sig = keys[2].Sign(SlashApprovalSigningMessage(id))
+	proposal, err := ApproveSlashProposal(ctx, id, custodians[2], sig.String())
+	assert.Nil(err)
+	assert.Equal(SlashProposalStatePending, proposal.State)
+	assert.Equal(2, proposal.Threshold)
+	assert.Len(proposal.Approvals, 1)
+	proposal, err = ApproveSlashProposal(ctx, id, custodians[2], sig.String())
+	assert.Nil(err)
+	assert.Len(proposal.Approvals, 1)
+
+	sig = keys[3].Sign(SlashApprovalSigningMessage(id))
+	proposal, err = ApproveSlashProposal(ctx, id, custodians[3], sig.String())
+	assert.Nil(err)
+	assert.Equal(SlashProposalStateFinal, proposal.State)
+	assert.Len(proposal.Approvals, 2)
+
+	proposals, err := ReadSlashProposals(ctx)
+	assert.Nil(err)
+	assert.Len(proposals, 1)
+	assert.Equal(SlashProposalStateFinal, proposals[0].State)
+}
+
+func testCustodianKey() (crypto.Key, string) {
+	seed := make([]byte, 64)
+	rand.Read(seed)
+	spend := crypto.NewKeyFromSeed(seed)
+	rand.Read(seed)
+	view := crypto.NewKeyFromSeed(seed)
+	addr := common.Address{PublicSpendKey: spend.Public(), PublicViewKey: view.Public()}
+	return spend, addr.String()
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/store"
+)
+
+const SnapshotMaxAttempts = 10
+
+// SnapshotFailure is a transfer the handler failed to process, it is skipped
+// once the error is permanent or the attempts are exhausted, and left for the
+// operator to resolve.
+type SnapshotFailure struct {
+	SnapshotID string
+	OpponentID string
+	AssetID    string
+	Amount     string
+	Memo       string
+	Error      string
+	Attempts   int
+	Skipped    bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+var snapshotFailuresColumns = []string{"snapshot_id", "opponent_id", "asset_id", "amount", "memo", "error", "attempts", "skipped", "created_at", "updated_at"}
+
+func snapshotFailureFromRow(row store.Row) (*SnapshotFailure, error) {
+	var f SnapshotFailure
+	err := row.Scan(&f.SnapshotID, &f.OpponentID, &f.AssetID, &f.Amount, &f.Memo, &f.Error, &f.Attempts, &f.Skipped, &f.CreatedAt, &f.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &f, err
+}
+
+var snapshotsColumns = []string{"snapshot_id", "opponent_id", "asset_id", "amount", "memo", "created_at", "processed_at"}
+
+func CheckSnapshotProcessed(ctx context.Context, id string) (bool, error) {
+	var count int
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM snapshots WHERE snapshot_id=?", id).Scan(&count)
+	})
+	if err != nil {
+		return false, session.TransactionError(ctx, err)
+	}
+	return count > 0, nil
+}
+
+func WriteSnapshotProcessed(ctx context.Context, transfer *bot.TransferView) error {
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := store.BuildInsertionSQL("snapshots", snapshotsColumns) + " ON CONFLICT (snapshot_id) DO NOTHING"
+		_, err := tx.ExecContext(ctx, query, transfer.SnapshotId, transfer.CounterUserId, transfer.AssetId, transfer.Amount, transfer.Memo, transfer.CreatedAt, time.Now())
+		return err
+	})
+	if err != nil {
+		return session.TransactionError(ctx, err)
+	}
+	return nil
+}
+
+// RecordSnapshotFailure counts a failed attempt to process the transfer. A
+// session error other than a server error will fail the same way again, so
+// the snapshot is skipped at once and marked processed, other errors are
+// retried until the attempts are exhausted.
+func RecordSnapshotFailure(ctx context.Context, transfer *bot.TransferView, cause error) (*SnapshotFailure, error) {
+	serr, ok := cause.(*session.Error)
+	permanent := ok && serr.Status != http.StatusInternalServerError
+	var failure *SnapshotFailure
+	err := session.Database(ctx).RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		t := time.Now()
+		query := store.BuildInsertionSQL("snapshot_failures", snapshotFailuresColumns) +
+			" ON CONFLICT (snapshot_id) DO UPDATE SET error=excluded.error,attempts=attempts+1,updated_at=excluded.updated_at"
+		_, err := tx.ExecContext(ctx, query, transfer.SnapshotId, transfer.CounterUserId, transfer.AssetId, transfer.Amount, transfer.Memo, cause.Error(), 1, false, t, t)
+		if err != nil {
+			return err
+		}
+		query = fmt.Sprintf("SELECT %s FROM snapshot_failures WHERE snapshot_id=?", strings.Join(snapshotFailuresColumns, ","))
+		failure, err = snapshotFailureFromRow(tx.QueryRowContext(ctx, query, transfer.SnapshotId))
+		if err != nil || (!permanent && failure.Attempts < SnapshotMaxAttempts) {
+			return err
+		}
+		failure.Skipped = true
+		_, err = tx.ExecContext(ctx, "UPDATE snapshot_failures SET skipped=? WHERE snapshot_id=?", failure.Skipped, failure.SnapshotID)
+		if err != nil {
+			return err
+		}
+		query = store.BuildInsertionSQL("snapshots", snapshotsColumns) + " ON CONFLICT (snapshot_id) DO NOTHING"
+		_, err = tx.ExecContext(ctx, query, transfer.SnapshotId, transfer.CounterUserId, transfer.AssetId, transfer.Amount, transfer.Memo, transfer.CreatedAt, t)
+		return err
+	})
+	if err != nil {
+		return nil, session.TransactionError(ctx, err)
+	}
+	return failure, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	checkpoint, err := ReadProperty(ctx, "snapshots-checkpoint")
+	assert.Nil(err)
+	seeded, err := time.Parse(time.RFC3339Nano, checkpoint)
+	assert.Nil(err)
+	assert.WithinDuration(time.Now(), seeded, time.Minute)
+	now := time.Now().Format(time.RFC3339Nano)
+	assert.Nil(WriteProperty(ctx, "snapshots-checkpoint", now))
+	assert.Nil(WriteProperty(ctx, "snapshots-checkpoint", now))
+	checkpoint, err = ReadProperty(ctx, "snapshots-checkpoint")
+	assert.Nil(err)
+	assert.Equal(now, checkpoint)
+
+	transfer := &bot.TransferView{
+		SnapshotId:    "9b2b8f4e-44a9-4f2d-b1b5-4f1a8c0a3f70",
+		CounterUserId: "e9e5b807-fa8b-455a-8dfa-b189d28310ff",
+		AssetId:       "c94ac88f-4671-3976-b60a-09064f1811e8",
+		Amount:        "100",
+		CreatedAt:     time.Now(),
+	}
+	processed, err := CheckSnapshotProcessed(ctx, transfer.SnapshotId)
+	assert.Nil(err)
+	assert.False(processed)
+	assert.Nil(WriteSnapshotProcessed(ctx, transfer))
+	assert.Nil(WriteSnapshotProcessed(ctx, transfer))
+	processed, err = CheckSnapshotProcessed(ctx, transfer.SnapshotId)
+	assert.Nil(err)
+	assert.True(processed)
+}
+
+func TestSnapshotFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	transfer := &bot.TransferView{
+		SnapshotId:    "9b2b8f4e-44a9-4f2d-b1b5-4f1a8c0a3f70",
+		CounterUserId: "e9e5b807-fa8b-455a-8dfa-b189d28310ff",
+		AssetId:       "c94ac88f-4671-3976-b60a-09064f1811e8",
+		Amount:        "100",
+		CreatedAt:     time.Now(),
+	}
+	for i := 1; i < SnapshotMaxAttempts; i++ {
+		failure, err := RecordSnapshotFailure(ctx, transfer, fmt.Errorf("timeout"))
+		assert.Nil(err)
+		assert.Equal(i, failure.Attempts)
+		assert.False(failure.Skipped)
+	}
+	processed, err := CheckSnapshotProcessed(ctx, transfer.SnapshotId)
+	assert.Nil(err)
+	assert.False(processed)
+	failure, err := RecordSnapshotFailure(ctx, transfer, fmt.Errorf("timeout"))
+	assert.Nil(err)
+	assert.Equal(SnapshotMaxAttempts, failure.Attempts)
+	assert.True(failure.Skipped)
+	processed, err = CheckSnapshotProcessed(ctx, transfer.SnapshotId)
+	assert.Nil(err)
+	assert.True(processed)
+
+	transfer.SnapshotId = "1f0ad2a3-5e7c-4c5e-9c0b-3c7a1b1f0c21"
+	failure, err = RecordSnapshotFailure(ctx, transfer, session.BadDataError(ctx))
+	assert.Nil(err)
+	assert.Equal(1, failure.Attempts)
+	assert.True(failure.Skipped)
+}
+
+func TestRefund(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := setupTestContext()
+	defer teardownTestContext(ctx)
+
+	transfer := &bot.TransferView{
+		SnapshotId:    "9b2b8f4e-44a9-4f2d-b1b5-4f1a8c0a3f70",
+		CounterUserId: "e9e5b807-fa8b-455a-8dfa-b189d28310ff",
+		AssetId:       "c94ac88f-4671-3976-b60a-09064f1811e8",
+		Amount:        "100",
+		CreatedAt:     time.Now(),
+	}
+	refund, err := CreateRefund(ctx, transfer, RefundReasonInvalidAsset)
+	assert.Nil(err)
+	assert.Equal(RefundTraceID(transfer.SnapshotId), refund.TraceID)
+	assert.Equal(RefundStatePending, refund.State)
+	again, err := CreateRefund(ctx, transfer, RefundReasonEmptyMemo)
+	assert.Nil(err)
+	assert.Equal(refund.TraceID, again.TraceID)
+	assert.Equal(RefundReasonInvalidAsset, again.Reason)
+
+	refunds, err := ReadRefunds(ctx, RefundStatePending, 10)
+	assert.Nil(err)
+	assert.Len(refunds, 1)
+	assert.Nil(UpdateRefundSent(ctx, refund.TraceID))
+	refunds, err = ReadRefunds(ctx, RefundStatePending, 10)
+	assert.Nil(err)
+	assert.Len(refunds, 0)
+	refunds, err = ReadRefunds(ctx, "", 10)
+	assert.Nil(err)
+	assert.Len(refunds, 1)
+	assert.Equal(RefundStateSent, refunds[0].State)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/MixinNetwork/safe/governance/config"
+)
+
+func authorizeAdmin(r *http.Request) bool {
+	token := config.AppConfig.Governance.AdminToken
+	if token == "" {
+		return false
+	}
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(auth), []byte(token)) == 1
+}
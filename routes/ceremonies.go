@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/dimfeld/httptreemux"
+)
+
+type ceremonyRequest struct {
+	Threshold int `json:"threshold"`
+}
+
+type ceremonyImpl struct{}
+
+func registerCeremony(router *httptreemux.TreeMux) {
+	impl := &ceremonyImpl{}
+
+	router.POST("/ceremonies", impl.create)
+	router.GET("/ceremonies", impl.index)
+	router.GET("/ceremonies/:id", impl.show)
+	router.POST("/ceremonies/:id/abort", impl.abort)
+}
+
+func (impl *ceremonyImpl) create(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	var body ceremonyRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	ceremony, err := models.CreateCeremony(r.Context(), body.Threshold)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderCeremony(w, r, ceremony)
+	}
+}
+
+func (impl *ceremonyImpl) index(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	ceremonies, err := models.ReadCeremonies(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderCeremonies(w, r, ceremonies)
+	}
+}
+
+func (impl *ceremonyImpl) show(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	ceremony, err := models.ReadCeremony(r.Context(), params["id"])
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else if ceremony == nil {
+		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
+	} else {
+		views.RenderCeremony(w, r, ceremony)
+	}
+}
+
+func (impl *ceremonyImpl) abort(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	ceremony, err := models.AbortCeremony(r.Context(), params["id"])
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderCeremony(w, r, ceremony)
+	}
+}
@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/dimfeld/httptreemux"
+)
+
+type custodianEpochRequest struct {
+	CeremonyID string   `json:"ceremony_id"`
+	Adds       []string `json:"adds"`
+	Removes    []string `json:"removes"`
+}
+
+type custodianApprovalRequest struct {
+	Approver  string `json:"approver"`
+	Signature string `json:"signature"`
+}
+
+type custodianImpl struct{}
+
+func registerCustodian(router *httptreemux.TreeMux) {
+	impl := &custodianImpl{}
+
+	router.POST("/custodian/epochs", impl.propose)
+	router.GET("/custodian/epochs", impl.index)
+	router.GET("/custodian/epochs/:epoch", impl.show)
+	router.POST("/custodian/epochs/:epoch/approvals", impl.approve)
+	router.POST("/custodian/epochs/:epoch/cancel", impl.cancel)
+}
+
+func (impl *custodianImpl) propose(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	var body custodianEpochRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	epoch, err := models.ProposeCustodianEpoch(r.Context(), body.CeremonyID, body.Adds, body.Removes)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		impl.render(w, r, epoch)
+	}
+}
+
+func (impl *custodianImpl) index(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	epochs, err := models.ReadCustodianEpochs(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderCustodianEpochs(w, r, epochs)
+	}
+}
+
+func (impl *custodianImpl) show(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	number, err := strconv.ParseInt(params["epoch"], 10, 64)
+	if err != nil {
+		views.RenderErrorResponse(w, r, session.BadDataErrorWithFieldAndData(r.Context(), "epoch", "invalid", params["epoch"]))
+		return
+	}
+	epoch, err := models.ReadCustodianEpoch(r.Context(), number)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else if epoch == nil {
+		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
+	} else {
+		impl.render(w, r, epoch)
+	}
+}
+
+func (impl *custodianImpl) approve(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	number, err := strconv.ParseInt(params["epoch"], 10, 64)
+	if err != nil {
+		views.RenderErrorResponse(w, r, session.BadDataErrorWithFieldAndData(r.Context(), "epoch", "invalid", params["epoch"]))
+		return
+	}
+	var body custodianApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	epoch, err := models.ApproveCustodianEpoch(r.Context(), number, body.Approver, body.Signature)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		impl.render(w, r, epoch)
+	}
+}
+
+func (impl *custodianImpl) cancel(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	number, err := strconv.ParseInt(params["epoch"], 10, 64)
+	if err != nil {
+		views.RenderErrorResponse(w, r, session.BadDataErrorWithFieldAndData(r.Context(), "epoch", "invalid", params["epoch"]))
+		return
+	}
+	epoch, err := models.CancelCustodianEpoch(r.Context(), number)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		impl.render(w, r, epoch)
+	}
+}
+
+func (impl *custodianImpl) render(w http.ResponseWriter, r *http.Request, epoch *models.CustodianEpoch) {
+	if epoch.Epoch < 2 {
+		views.RenderCustodianEpoch(w, r, epoch, nil)
+		return
+	}
+	update, err := models.BuildCustodianUpdate(r.Context(), epoch.Epoch)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderCustodianEpoch(w, r, epoch, update)
+	}
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/dimfeld/httptreemux"
+)
+
+type depositRequest struct {
+	Raw  string `json:"raw"`
+	Hash string `json:"hash"`
+}
+
+type depositSignatureRequest struct {
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+type depositImpl struct{}
+
+func registerDeposit(router *httptreemux.TreeMux) {
+	impl := &depositImpl{}
+
+	router.POST("/deposits", impl.create)
+	router.GET("/deposits/unsigned", impl.unsigned)
+	router.GET("/deposits/:hash", impl.show)
+	router.POST("/deposits/:hash/signatures", impl.sign)
+}
+
+func (impl *depositImpl) create(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	var body depositRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	var deposit *models.Deposit
+	var err error
+	if body.Raw != "" {
+		deposit, err = models.IngestDeposit(r.Context(), body.Raw)
+	} else {
+		deposit, err = models.IngestKernelDeposit(r.Context(), body.Hash)
+	}
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderDeposit(w, r, deposit)
+	}
+}
+
+func (impl *depositImpl) unsigned(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	deposits, err := models.ReadUnsignedDeposits(r.Context(), r.URL.Query().Get("signer"))
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderDeposits(w, r, deposits)
+	}
+}
+
+func (impl *depositImpl) show(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	deposit, err := models.ReadDeposit(r.Context(), params["hash"])
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else if deposit == nil {
+		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
+	} else {
+		views.RenderDeposit(w, r, deposit)
+	}
+}
+
+func (impl *depositImpl) sign(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	var body depositSignatureRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	deposit, err := models.SignDeposit(r.Context(), params["hash"], body.Signer, body.Signature)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderDeposit(w, r, deposit)
+	}
+}
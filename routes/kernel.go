@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/dimfeld/httptreemux"
+)
+
+type kernelImpl struct{}
+
+func registerKernel(router *httptreemux.TreeMux) {
+	impl := &kernelImpl{}
+
+	router.GET("/kernel/nodes", impl.index)
+	router.GET("/kernel/nodes/:id/history", impl.history)
+}
+
+func (impl *kernelImpl) index(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	nodes, err := models.ReadKernelNodes(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+		return
+	}
+	safe, err := models.ReadNodeSetByKernel(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+		return
+	}
+	if r.URL.Query().Get("detached") == "true" {
+		var detached []*models.KernelNode
+		for _, n := range nodes {
+			if safe[n.NodeID] != nil && n.State != models.KernelNodeStateAccepted {
+				detached = append(detached, n)
+			}
+		}
+		nodes = detached
+	}
+	views.RenderKernelNodes(w, r, nodes, safe)
+}
+
+func (impl *kernelImpl) history(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	node, err := models.ReadKernelNode(r.Context(), params["id"])
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+		return
+	} else if node == nil {
+		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
+		return
+	}
+	events, err := models.ReadKernelNodeEvents(r.Context(), node.NodeID)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderKernelNodeEvents(w, r, events)
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/MixinNetwork/safe/governance/models"
 	"github.com/MixinNetwork/safe/governance/session"
@@ -11,7 +12,12 @@ import (
 )
 
 type nodeRequest struct {
-	Extra string `json:"extra"`
+	Extra  string `json:"extra"`
+	Bundle string `json:"bundle"`
+}
+
+type nodeStateRequest struct {
+	Signature string `json:"signature"`
 }
 
 type nodeImpl struct{}
@@ -21,6 +27,11 @@ func registerNode(router *httptreemux.TreeMux) {
 
 	router.POST("/nodes", impl.create)
 	router.GET("/nodes", impl.index)
+	router.GET("/nodes/:custodian/liveness", impl.liveness)
+	router.POST("/nodes/:custodian/keystore", impl.keystore)
+	router.POST("/nodes/:custodian/migration", impl.migration)
+	router.POST("/nodes/:custodian/revoke", impl.revoke)
+	router.POST("/bundles", impl.bundle)
 }
 
 func (impl *nodeImpl) create(w http.ResponseWriter, r *http.Request, _ map[string]string) {
@@ -29,18 +40,94 @@ func (impl *nodeImpl) create(w http.ResponseWriter, r *http.Request, _ map[strin
 		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
 		return
 	}
-	if node, err := models.CreateNodeByExtra(r.Context(), body.Extra); err != nil {
+	var node *models.Node
+	var err error
+	if body.Bundle != "" {
+		node, err = models.CreateNodeByBundle(r.Context(), body.Bundle)
+	} else {
+		node, err = models.CreateNodeByExtra(r.Context(), body.Extra)
+	}
+	if err != nil {
 		views.RenderErrorResponse(w, r, err)
 	} else {
 		views.RenderNode(w, r, node)
 	}
 }
 
+func (impl *nodeImpl) bundle(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body nodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	if status, err := models.InspectBundle(r.Context(), body.Bundle); err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderBundleStatus(w, r, status)
+	}
+}
+
 func (impl *nodeImpl) index(w http.ResponseWriter, r *http.Request, _ map[string]string) {
-	nodes, err := models.ReadNodes(r.Context())
+	var nodes []*models.Node
+	var err error
+	if state := r.URL.Query().Get("state"); state != "" {
+		nodes, err = models.ReadNodesByState(r.Context(), state)
+	} else {
+		nodes, err = models.ReadNodes(r.Context())
+	}
 	if err != nil {
 		views.RenderErrorResponse(w, r, err)
 	} else {
 		views.RenderNodes(w, r, nodes)
 	}
 }
+
+func (impl *nodeImpl) liveness(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	node, err := models.ReadNode(r.Context(), params["custodian"])
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+		return
+	} else if node == nil {
+		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
+		return
+	}
+	weeks, err := models.ReadNodeLiveness(r.Context(), node, time.Now())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderNodeLiveness(w, r, node, weeks)
+	}
+}
+
+func (impl *nodeImpl) keystore(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	impl.confirm(w, r, params["custodian"], models.NodeStateKeystoreDelivered)
+}
+
+func (impl *nodeImpl) migration(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	impl.confirm(w, r, params["custodian"], models.NodeStateMigrated)
+}
+
+func (impl *nodeImpl) confirm(w http.ResponseWriter, r *http.Request, custodian, state string) {
+	var body nodeStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	if node, err := models.ConfirmNodeState(r.Context(), custodian, state, body.Signature); err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderNode(w, r, node)
+	}
+}
+
+func (impl *nodeImpl) revoke(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	if node, err := models.UpdateNodeState(r.Context(), params["custodian"], models.NodeStateRevoked); err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderNode(w, r, node)
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/dimfeld/httptreemux"
+)
+
+type refundImpl struct{}
+
+func registerRefund(router *httptreemux.TreeMux) {
+	impl := &refundImpl{}
+
+	router.GET("/refunds", impl.index)
+}
+
+func (impl *refundImpl) index(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !authorizeAdmin(r) {
+		views.RenderErrorResponse(w, r, session.AuthorizationError(r.Context()))
+		return
+	}
+	refunds, err := models.ReadRefunds(r.Context(), r.URL.Query().Get("state"), 500)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderRefunds(w, r, refunds)
+	}
+}
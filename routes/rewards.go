@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+)
+
+func rewards(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	query := r.URL.Query()
+	day, err := time.Parse("2006-01-02", query.Get("day"))
+	if err != nil {
+		views.RenderErrorResponse(w, r, session.BadDataErrorWithFieldAndData(r.Context(), "day", "invalid", query.Get("day")))
+		return
+	}
+	table, err := models.BuildRewardTable(r.Context(), day, query.Get("mint"))
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderRewardTable(w, r, table)
+	}
+}
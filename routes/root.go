@@ -17,6 +17,16 @@ func RegisterRoutes(router *httptreemux.TreeMux) {
 	router.GET("/template", template)
 
 	registerNode(router)
+	registerRefund(router)
+	registerKernel(router)
+	registerSlash(router)
+	registerCeremony(router)
+	registerCustodian(router)
+	registerDeposit(router)
+	router.GET("/epoch", epoch)
+	router.GET("/rewards", rewards)
+	router.GET("/pledge", pledge)
+	router.GET("/burns", burns)
 }
 
 func health(w http.ResponseWriter, r *http.Request, _ map[string]string) {
@@ -38,6 +48,33 @@ func template(w http.ResponseWriter, r *http.Request, _ map[string]string) {
 	views.RenderTemplate(w, r, list)
 }
 
+func epoch(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	epoch, err := models.ReadRegistrationEpoch(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderRegistrationEpoch(w, r, epoch)
+	}
+}
+
+func pledge(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	pledge, nodes, err := models.BuildPledge(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderPledge(w, r, pledge, nodes)
+	}
+}
+
+func burns(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	ledger, err := models.ReadBurnLedger(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderBurnLedger(w, r, ledger)
+	}
+}
+
 func RegisterHanders(router *httptreemux.TreeMux) {
 	router.MethodNotAllowedHandler = func(w http.ResponseWriter, r *http.Request, _ map[string]httptreemux.HandlerFunc) {
 		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
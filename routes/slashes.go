@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+	"github.com/MixinNetwork/safe/governance/session"
+	"github.com/MixinNetwork/safe/governance/views"
+	"github.com/dimfeld/httptreemux"
+)
+
+type evidenceRequest struct {
+	Type      string `json:"type"`
+	Custodian string `json:"custodian"`
+	Reporter  string `json:"reporter"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type slashApprovalRequest struct {
+	Approver  string `json:"approver"`
+	Signature string `json:"signature"`
+}
+
+type slashImpl struct{}
+
+func registerSlash(router *httptreemux.TreeMux) {
+	impl := &slashImpl{}
+
+	router.POST("/evidences", impl.createEvidence)
+	router.GET("/evidences", impl.evidences)
+	router.GET("/slashes", impl.index)
+	router.GET("/slashes/:id", impl.show)
+	router.POST("/slashes/:id/approvals", impl.approve)
+	router.GET("/challenges", impl.challenges)
+}
+
+func (impl *slashImpl) createEvidence(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body evidenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	evidence, err := models.CreateEvidence(r.Context(), body.Type, body.Custodian, body.Reporter, body.Payload, body.Signature)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderEvidence(w, r, evidence)
+	}
+}
+
+func (impl *slashImpl) evidences(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	evidences, err := models.ReadEvidences(r.Context(), r.URL.Query().Get("custodian"))
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderEvidences(w, r, evidences)
+	}
+}
+
+func (impl *slashImpl) index(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	proposals, err := models.ReadSlashProposals(r.Context())
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderSlashProposals(w, r, proposals)
+	}
+}
+
+func (impl *slashImpl) show(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	proposal, err := models.ReadSlashProposal(r.Context(), params["id"])
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else if proposal == nil {
+		views.RenderErrorResponse(w, r, session.NotFoundError(r.Context()))
+	} else {
+		views.RenderSlashProposal(w, r, proposal)
+	}
+}
+
+func (impl *slashImpl) approve(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	var body slashApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		views.RenderErrorResponse(w, r, session.BadRequestError(r.Context()))
+		return
+	}
+	proposal, err := models.ApproveSlashProposal(r.Context(), params["id"], body.Approver, body.Signature)
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderSlashProposal(w, r, proposal)
+	}
+}
+
+func (impl *slashImpl) challenges(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	challenges, err := models.ReadChallenges(r.Context(), r.URL.Query().Get("custodian"))
+	if err != nil {
+		views.RenderErrorResponse(w, r, err)
+	} else {
+		views.RenderChallenges(w, r, challenges)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/MixinNetwork/safe/governance/externals"
 	"github.com/MixinNetwork/safe/governance/store"
 	"github.com/unrolled/render"
 )
@@ -14,6 +15,7 @@ const (
 	keyRequest       contextValueKey = 0
 	keyDatabase      contextValueKey = 1
 	keyRender        contextValueKey = 3
+	keyKernel        contextValueKey = 5
 	keyRemoteAddress contextValueKey = 11
 	keyRequestBody   contextValueKey = 13
 )
@@ -23,6 +25,11 @@ func Database(ctx context.Context) *store.Database {
 	return v
 }
 
+func Kernel(ctx context.Context) externals.KernelClient {
+	v, _ := ctx.Value(keyKernel).(externals.KernelClient)
+	return v
+}
+
 func Render(ctx context.Context) *render.Render {
 	v, _ := ctx.Value(keyRender).(*render.Render)
 	return v
@@ -47,6 +54,10 @@ func WithDatabase(ctx context.Context, database *store.Database) context.Context
 	return context.WithValue(ctx, keyDatabase, database)
 }
 
+func WithKernel(ctx context.Context, kernel externals.KernelClient) context.Context {
+	return context.WithValue(ctx, keyKernel, kernel)
+}
+
 func WithRender(ctx context.Context, render *render.Render) context.Context {
 	return context.WithValue(ctx, keyRender, render)
 }
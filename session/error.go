@@ -81,6 +81,31 @@ func TooManyPendingTransactions(ctx context.Context) *Error {
 	return createError(ctx, http.StatusAccepted, 10003, "Too many pending transactions.", nil)
 }
 
+func InvalidStateTransitionError(ctx context.Context, from, to string) *Error {
+	description := "The state transition is not allowed."
+	err := createError(ctx, http.StatusAccepted, 10004, description, fmt.Errorf("[STATE %s => %s]", from, to))
+	err.Extra = map[string]string{
+		"from": from,
+		"to":   to,
+	}
+	return err
+}
+
+func NoSeatsLeftError(ctx context.Context) *Error {
+	description := "No app seats left."
+	return createError(ctx, http.StatusAccepted, 10005, description, nil)
+}
+
+func RegistrationClosedError(ctx context.Context) *Error {
+	description := "The registration is closed."
+	return createError(ctx, http.StatusAccepted, 10006, description, nil)
+}
+
+func InvalidChallengeError(ctx context.Context) *Error {
+	description := "The challenged node is not available."
+	return createError(ctx, http.StatusAccepted, 10007, description, nil)
+}
+
 func InsufficientAccountError(ctx context.Context) *Error {
 	description := "Insufficient account quotas."
 	return createError(ctx, http.StatusAccepted, 10301, description, nil)
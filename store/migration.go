@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+const migrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version     INTEGER NOT NULL,
+  name        VARCHAR NOT NULL,
+  applied_at  TIMESTAMP NOT NULL,
+  PRIMARY KEY ('version')
+);`
+
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt sql.NullTime
+	query     string
+}
+
+func readMigrations() ([]*Migration, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []*Migration
+	seen := make(map[int]bool)
+	for _, f := range files {
+		name := strings.TrimSuffix(strings.TrimPrefix(f, "migrations/"), ".sql")
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %s", f)
+		}
+		if seen[version] {
+			return nil, fmt.Errorf("duplicated migration version %d", version)
+		}
+		seen[version] = true
+		data, err := migrationsFS.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, &Migration{Version: version, Name: name, query: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (s *Database) MigrationStatus(ctx context.Context) ([]*Migration, error) {
+	migrations, err := readMigrations()
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.ExecContext(ctx, migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT version,applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		err = rows.Scan(&version, &at)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if at, found := applied[m.Version]; found {
+			m.AppliedAt = sql.NullTime{Time: at, Valid: true}
+		}
+	}
+	return migrations, nil
+}
+
+func (s *Database) Migrate(ctx context.Context) ([]*Migration, error) {
+	migrations, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []*Migration
+	for _, m := range migrations {
+		if m.AppliedAt.Valid {
+			continue
+		}
+		err = s.RunInTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.query)
+			if err != nil {
+				return fmt.Errorf("migration %s => %v", m.Name, err)
+			}
+			t := time.Now()
+			query := BuildInsertionSQL("schema_migrations", []string{"version", "name", "applied_at"})
+			_, err = tx.ExecContext(ctx, query, m.Version, m.Name, t)
+			m.AppliedAt = sql.NullTime{Time: t, Valid: true}
+			return err
+		})
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "governance.sqlite3")
+	db, err := openDatabase(path)
+	assert.Nil(err)
+	defer db.Close()
+
+	migrations, err := db.MigrationStatus(ctx)
+	assert.Nil(err)
+	assert.NotEmpty(migrations)
+	for i, m := range migrations {
+		assert.False(m.AppliedAt.Valid)
+		if i > 0 {
+			assert.Less(migrations[i-1].Version, m.Version)
+		}
+	}
+
+	applied, err := db.Migrate(ctx)
+	assert.Nil(err)
+	assert.Len(applied, len(migrations))
+	applied, err = db.Migrate(ctx)
+	assert.Nil(err)
+	assert.Len(applied, 0)
+
+	migrations, err = db.MigrationStatus(ctx)
+	assert.Nil(err)
+	for _, m := range migrations {
+		assert.True(m.AppliedAt.Valid)
+	}
+}
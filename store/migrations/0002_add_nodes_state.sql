@@ -0,0 +1,5 @@
+ALTER TABLE nodes ADD COLUMN state VARCHAR NOT NULL DEFAULT 'registered';
+
+UPDATE nodes SET state='app_assigned' WHERE app_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS nodes_by_state ON nodes(state);
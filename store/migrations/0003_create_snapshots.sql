@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS properties (
+  key         VARCHAR NOT NULL,
+  value       VARCHAR NOT NULL,
+  updated_at  TIMESTAMP NOT NULL,
+  PRIMARY KEY ('key')
+);
+
+CREATE TABLE IF NOT EXISTS snapshots (
+  snapshot_id  VARCHAR NOT NULL,
+  opponent_id  VARCHAR NOT NULL,
+  asset_id     VARCHAR NOT NULL,
+  amount       VARCHAR NOT NULL,
+  memo         VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  processed_at TIMESTAMP NOT NULL,
+  PRIMARY KEY ('snapshot_id')
+);
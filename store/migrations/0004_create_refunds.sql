@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS refunds (
+  trace_id     VARCHAR NOT NULL,
+  snapshot_id  VARCHAR NOT NULL,
+  opponent_id  VARCHAR NOT NULL,
+  asset_id     VARCHAR NOT NULL,
+  amount       VARCHAR NOT NULL,
+  memo         VARCHAR NOT NULL,
+  reason       VARCHAR NOT NULL,
+  state        VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  updated_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('trace_id')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS refunds_by_snapshot ON refunds(snapshot_id);
+CREATE INDEX IF NOT EXISTS refunds_by_state_created ON refunds(state, created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS app_seats (
+  app_id       VARCHAR NOT NULL,
+  position     INTEGER NOT NULL,
+  custodian    VARCHAR,
+  reserved_at  TIMESTAMP,
+  created_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('app_id')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS app_seats_by_position ON app_seats(position);
+CREATE UNIQUE INDEX IF NOT EXISTS app_seats_by_custodian ON app_seats(custodian);
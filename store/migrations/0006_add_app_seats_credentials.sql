@@ -0,0 +1,2 @@
+ALTER TABLE app_seats ADD COLUMN credentials VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE app_seats ADD COLUMN retired_at TIMESTAMP;
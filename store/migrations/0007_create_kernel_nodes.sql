@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS kernel_nodes (
+  node_id      VARCHAR NOT NULL,
+  signer       VARCHAR NOT NULL,
+  payee        VARCHAR NOT NULL,
+  state        VARCHAR NOT NULL,
+  timestamp    INTEGER NOT NULL,
+  transaction_hash VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  updated_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('node_id')
+);
+
+CREATE INDEX IF NOT EXISTS kernel_nodes_by_payee ON kernel_nodes(payee);
+
+CREATE TABLE IF NOT EXISTS kernel_node_events (
+  event_id     VARCHAR NOT NULL,
+  node_id      VARCHAR NOT NULL,
+  signer       VARCHAR NOT NULL,
+  payee        VARCHAR NOT NULL,
+  state        VARCHAR NOT NULL,
+  timestamp    INTEGER NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('event_id')
+);
+
+CREATE INDEX IF NOT EXISTS kernel_node_events_by_node_created ON kernel_node_events(node_id, created_at);
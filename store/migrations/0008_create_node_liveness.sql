@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS node_liveness (
+  custodian          VARCHAR NOT NULL,
+  week               VARCHAR NOT NULL,
+  kernel_signatures  INTEGER NOT NULL,
+  safe_signatures    INTEGER NOT NULL,
+  updated_at         TIMESTAMP NOT NULL,
+  PRIMARY KEY ('custodian', 'week')
+);
+
+CREATE TABLE IF NOT EXISTS liveness_signatures (
+  signature_id  VARCHAR NOT NULL,
+  custodian     VARCHAR NOT NULL,
+  kind          VARCHAR NOT NULL,
+  week          VARCHAR NOT NULL,
+  created_at    TIMESTAMP NOT NULL,
+  PRIMARY KEY ('signature_id')
+);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS evidences (
+  evidence_id  VARCHAR NOT NULL,
+  type         VARCHAR NOT NULL,
+  custodian    VARCHAR NOT NULL,
+  reporter     VARCHAR NOT NULL,
+  payload      VARCHAR NOT NULL,
+  signature    VARCHAR NOT NULL,
+  verified     BOOLEAN NOT NULL,
+  proposal_id  VARCHAR,
+  created_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('evidence_id')
+);
+
+CREATE INDEX IF NOT EXISTS evidences_by_custodian_created ON evidences(custodian, created_at);
+CREATE INDEX IF NOT EXISTS evidences_by_proposal ON evidences(proposal_id);
+
+CREATE TABLE IF NOT EXISTS slash_proposals (
+  proposal_id  VARCHAR NOT NULL,
+  custodian    VARCHAR NOT NULL,
+  state        VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  updated_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('proposal_id')
+);
+
+CREATE INDEX IF NOT EXISTS slash_proposals_by_custodian_state ON slash_proposals(custodian, state);
+
+CREATE TABLE IF NOT EXISTS slash_approvals (
+  proposal_id  VARCHAR NOT NULL,
+  approver     VARCHAR NOT NULL,
+  signature    VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('proposal_id', 'approver')
+);
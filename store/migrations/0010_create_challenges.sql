@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS challenges (
+  challenge_id  VARCHAR NOT NULL,
+  snapshot_id   VARCHAR NOT NULL,
+  challenger    VARCHAR NOT NULL,
+  custodian     VARCHAR NOT NULL,
+  app_id        VARCHAR NOT NULL,
+  message       VARCHAR NOT NULL,
+  signature     VARCHAR NOT NULL,
+  state         VARCHAR NOT NULL,
+  deadline      TIMESTAMP NOT NULL,
+  created_at    TIMESTAMP NOT NULL,
+  updated_at    TIMESTAMP NOT NULL,
+  PRIMARY KEY ('challenge_id')
+);
+
+CREATE INDEX IF NOT EXISTS challenges_by_state_deadline ON challenges(state, deadline);
+CREATE INDEX IF NOT EXISTS challenges_by_custodian_created ON challenges(custodian, created_at);
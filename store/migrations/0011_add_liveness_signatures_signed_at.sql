@@ -0,0 +1,3 @@
+ALTER TABLE liveness_signatures ADD COLUMN signed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS liveness_signatures_by_signed ON liveness_signatures(signed_at);
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS ceremonies (
+  ceremony_id    VARCHAR NOT NULL,
+  threshold      INTEGER NOT NULL,
+  participants   INTEGER NOT NULL,
+  round          INTEGER NOT NULL,
+  relayed_round  INTEGER NOT NULL,
+  state          VARCHAR NOT NULL,
+  group_key      VARCHAR NOT NULL,
+  created_at     TIMESTAMP NOT NULL,
+  updated_at     TIMESTAMP NOT NULL,
+  PRIMARY KEY ('ceremony_id')
+);
+
+CREATE TABLE IF NOT EXISTS ceremony_participants (
+  ceremony_id  VARCHAR NOT NULL,
+  custodian    VARCHAR NOT NULL,
+  app_id       VARCHAR NOT NULL,
+  position     INTEGER NOT NULL,
+  commitment   VARCHAR NOT NULL,
+  group_key    VARCHAR NOT NULL,
+  signature    VARCHAR NOT NULL,
+  updated_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('ceremony_id', 'custodian')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ceremony_participants_by_app ON ceremony_participants(ceremony_id, app_id);
+
+CREATE TABLE IF NOT EXISTS ceremony_shares (
+  ceremony_id  VARCHAR NOT NULL,
+  sender       VARCHAR NOT NULL,
+  receiver     VARCHAR NOT NULL,
+  share        VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('ceremony_id', 'sender', 'receiver')
+);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS custodian_epochs (
+  epoch        INTEGER NOT NULL,
+  threshold    INTEGER NOT NULL,
+  group_key    VARCHAR NOT NULL,
+  ceremony_id  VARCHAR NOT NULL,
+  state        VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  updated_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('epoch')
+);
+
+CREATE TABLE IF NOT EXISTS custodian_epoch_members (
+  epoch      INTEGER NOT NULL,
+  custodian  VARCHAR NOT NULL,
+  PRIMARY KEY ('epoch', 'custodian')
+);
+
+CREATE TABLE IF NOT EXISTS custodian_epoch_approvals (
+  epoch       INTEGER NOT NULL,
+  approver    VARCHAR NOT NULL,
+  signature   VARCHAR NOT NULL,
+  created_at  TIMESTAMP NOT NULL,
+  PRIMARY KEY ('epoch', 'approver')
+);
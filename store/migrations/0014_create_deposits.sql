@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS deposits (
+  transaction_hash     VARCHAR NOT NULL,
+  payload              VARCHAR NOT NULL,
+  chain                VARCHAR NOT NULL,
+  asset_key            VARCHAR NOT NULL,
+  deposit_hash         VARCHAR NOT NULL,
+  output_index         INTEGER NOT NULL,
+  amount               VARCHAR NOT NULL,
+  source               VARCHAR NOT NULL,
+  state                VARCHAR NOT NULL,
+  domain_signature     VARCHAR NOT NULL,
+  custodian_signature  VARCHAR NOT NULL,
+  custodian_epoch      INTEGER NOT NULL,
+  snapshot_hash        VARCHAR NOT NULL,
+  created_at           TIMESTAMP NOT NULL,
+  updated_at           TIMESTAMP NOT NULL,
+  PRIMARY KEY ('transaction_hash')
+);
+
+CREATE INDEX IF NOT EXISTS deposits_by_state_created ON deposits(state, created_at);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS node_fees (
+  custodian    VARCHAR NOT NULL,
+  snapshot_id  VARCHAR NOT NULL,
+  asset_id     VARCHAR NOT NULL,
+  amount       VARCHAR NOT NULL,
+  burn_id      VARCHAR NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('custodian')
+);
+
+CREATE INDEX IF NOT EXISTS node_fees_by_burn ON node_fees(burn_id);
+
+CREATE TABLE IF NOT EXISTS burns (
+  burn_id           VARCHAR NOT NULL,
+  asset_id          VARCHAR NOT NULL,
+  amount            VARCHAR NOT NULL,
+  address           VARCHAR NOT NULL,
+  state             VARCHAR NOT NULL,
+  transaction_hash  VARCHAR NOT NULL,
+  created_at        TIMESTAMP NOT NULL,
+  updated_at        TIMESTAMP NOT NULL,
+  PRIMARY KEY ('burn_id')
+);
+
+CREATE INDEX IF NOT EXISTS burns_by_state_created ON burns(state, created_at);
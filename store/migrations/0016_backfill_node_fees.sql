@@ -0,0 +1,5 @@
+INSERT OR IGNORE INTO node_fees (custodian, snapshot_id, asset_id, amount, burn_id, created_at)
+  SELECT n.custodian, s.snapshot_id, s.asset_id, s.amount, '', s.created_at
+  FROM nodes n JOIN snapshots s ON s.memo=n.mixin_hash
+  WHERE n.app_id IS NOT NULL AND s.snapshot_id NOT IN (SELECT snapshot_id FROM refunds)
+  ORDER BY s.created_at;
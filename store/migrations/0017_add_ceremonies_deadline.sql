@@ -0,0 +1,5 @@
+ALTER TABLE ceremonies ADD COLUMN deadline TIMESTAMP;
+
+UPDATE ceremonies SET deadline=updated_at;
+
+ALTER TABLE ceremony_participants ADD COLUMN proof VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE nodes ADD COLUMN epoch VARCHAR NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS nodes_by_epoch ON nodes(epoch);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS extra_nonces (
+  network_id    VARCHAR NOT NULL,
+  nonce         VARCHAR NOT NULL,
+  payload_hash  VARCHAR NOT NULL,
+  created_at    TIMESTAMP NOT NULL,
+  PRIMARY KEY ('network_id', 'nonce')
+);
@@ -0,0 +1,15 @@
+INSERT OR IGNORE INTO properties (key,value,updated_at) VALUES ('snapshots-checkpoint', strftime('%Y-%m-%dT%H:%M:%fZ', 'now'), datetime('now'));
+
+CREATE TABLE IF NOT EXISTS snapshot_failures (
+  snapshot_id  VARCHAR NOT NULL,
+  opponent_id  VARCHAR NOT NULL,
+  asset_id     VARCHAR NOT NULL,
+  amount       VARCHAR NOT NULL,
+  memo         VARCHAR NOT NULL,
+  error        VARCHAR NOT NULL,
+  attempts     INTEGER NOT NULL,
+  skipped      BOOLEAN NOT NULL,
+  created_at   TIMESTAMP NOT NULL,
+  updated_at   TIMESTAMP NOT NULL,
+  PRIMARY KEY ('snapshot_id')
+);
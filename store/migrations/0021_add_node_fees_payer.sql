@@ -0,0 +1,4 @@
+ALTER TABLE node_fees ADD COLUMN payer VARCHAR NOT NULL DEFAULT '';
+
+UPDATE node_fees SET payer=(SELECT s.opponent_id FROM snapshots s WHERE s.snapshot_id=node_fees.snapshot_id)
+  WHERE snapshot_id IN (SELECT snapshot_id FROM snapshots);
@@ -3,7 +3,6 @@ package store
 import (
 	"context"
 	"database/sql"
-	_ "embed"
 	"fmt"
 	"strings"
 
@@ -11,21 +10,30 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-//go:embed schema.sql
-var schemasql []byte
-
 type Database struct {
 	db *sql.DB
 }
 
 func OpenDatabase() (*Database, error) {
-	path := config.AppConfig.Database.Path
-	dsn := fmt.Sprintf("file:%s?mode=rwc&_journal_mode=WAL&cache=private", path)
-	db, err := sql.Open("sqlite", dsn)
+	s, err := OpenDatabaseWithoutMigration()
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.Migrate(context.Background())
 	if err != nil {
+		s.Close()
 		return nil, err
 	}
-	_, err = db.Exec(string(schemasql))
+	return s, nil
+}
+
+func OpenDatabaseWithoutMigration() (*Database, error) {
+	return openDatabase(config.AppConfig.Database.Path)
+}
+
+func openDatabase(path string) (*Database, error) {
+	dsn := fmt.Sprintf("file:%s?mode=rwc&_journal_mode=WAL&cache=private", path)
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,33 @@
+package views
+
+import (
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type BundleView struct {
+	Version   int      `json:"version"`
+	Custodian string   `json:"custodian"`
+	Payee     string   `json:"payee"`
+	KernelID  string   `json:"kernel_id"`
+	Signed    []string `json:"signed"`
+	Missing   []string `json:"missing"`
+	Invalid   []string `json:"invalid"`
+	Complete  bool     `json:"complete"`
+}
+
+func RenderBundleStatus(w http.ResponseWriter, r *http.Request, status *models.BundleStatus) {
+	e := status.Extra
+	view := &BundleView{
+		Version:   int(e.Version),
+		Custodian: e.Custodian.String(),
+		Payee:     e.Payee.String(),
+		KernelID:  e.NodeID.String(),
+		Signed:    append([]string{}, status.Signed...),
+		Missing:   append([]string{}, status.Missing...),
+		Invalid:   append([]string{}, status.Invalid...),
+		Complete:  len(status.Missing) == 0 && len(status.Invalid) == 0,
+	}
+	RenderDataResponse(w, r, view)
+}
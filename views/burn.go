@@ -0,0 +1,79 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type BurnNodeView struct {
+	Custodian  string `json:"custodian"`
+	SnapshotID string `json:"snapshot_id"`
+	Amount     string `json:"amount"`
+}
+
+type BurnView struct {
+	BurnID          string          `json:"burn_id"`
+	AssetID         string          `json:"asset_id"`
+	Amount          string          `json:"amount"`
+	State           string          `json:"state"`
+	TransactionHash string          `json:"transaction_hash"`
+	Nodes           []*BurnNodeView `json:"nodes"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+type BurnLedgerView struct {
+	Address       string      `json:"address"`
+	SpendSeed     string      `json:"spend_seed"`
+	ViewSeed      string      `json:"view_seed"`
+	Fee           string      `json:"fee"`
+	Registrations int         `json:"registrations"`
+	Unrecorded    []string    `json:"unrecorded"`
+	Fees          string      `json:"fees"`
+	Burned        string      `json:"burned"`
+	Pending       string      `json:"pending"`
+	Burns         []*BurnView `json:"burns"`
+}
+
+func buildBurnView(b *models.Burn) *BurnView {
+	view := &BurnView{
+		BurnID:          b.BurnID,
+		AssetID:         b.AssetID,
+		Amount:          b.Amount,
+		State:           b.State,
+		TransactionHash: b.TransactionHash,
+		Nodes:           make([]*BurnNodeView, len(b.Fees)),
+		CreatedAt:       b.CreatedAt,
+		UpdatedAt:       b.UpdatedAt,
+	}
+	for i, f := range b.Fees {
+		view.Nodes[i] = &BurnNodeView{
+			Custodian:  f.Custodian,
+			SnapshotID: f.SnapshotID,
+			Amount:     f.Amount,
+		}
+	}
+	return view
+}
+
+func RenderBurnLedger(w http.ResponseWriter, r *http.Request, ledger *models.BurnLedger) {
+	view := &BurnLedgerView{
+		Address:       ledger.Address,
+		SpendSeed:     extra.BurnSpendSeed,
+		ViewSeed:      extra.BurnViewSeed,
+		Fee:           ledger.Fee,
+		Registrations: ledger.Registrations,
+		Unrecorded:    ledger.Unrecorded,
+		Fees:          ledger.Fees.Persist(),
+		Burned:        ledger.Burned.Persist(),
+		Pending:       ledger.Pending.Persist(),
+		Burns:         make([]*BurnView, len(ledger.Burns)),
+	}
+	for i, b := range ledger.Burns {
+		view.Burns[i] = buildBurnView(b)
+	}
+	RenderDataResponse(w, r, view)
+}
@@ -0,0 +1,68 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type CeremonyParticipantView struct {
+	Custodian  string `json:"custodian"`
+	Position   int    `json:"position"`
+	Commitment string `json:"commitment"`
+	Proof      string `json:"proof"`
+	GroupKey   string `json:"group_key"`
+	Signature  string `json:"signature"`
+}
+
+type CeremonyView struct {
+	CeremonyID   string                     `json:"ceremony_id"`
+	Threshold    int                        `json:"threshold"`
+	Round        int                        `json:"round"`
+	State        string                     `json:"state"`
+	GroupKey     string                     `json:"group_key"`
+	Deadline     time.Time                  `json:"deadline"`
+	Participants []*CeremonyParticipantView `json:"participants"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	UpdatedAt    time.Time                  `json:"updated_at"`
+}
+
+func buildCeremonyView(c *models.Ceremony) *CeremonyView {
+	view := &CeremonyView{
+		CeremonyID:   c.CeremonyID,
+		Threshold:    c.Threshold,
+		Round:        c.Round,
+		State:        c.State,
+		GroupKey:     c.GroupKey,
+		Deadline:     c.Deadline,
+		Participants: make([]*CeremonyParticipantView, len(c.Members)),
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+	for i, m := range c.Members {
+		view.Participants[i] = &CeremonyParticipantView{
+			Custodian: m.Custodian,
+			Position:  m.Position,
+			GroupKey:  m.GroupKey,
+			Signature: m.Signature,
+		}
+		if c.CommitmentsRevealed() {
+			view.Participants[i].Commitment = m.Commitment
+			view.Participants[i].Proof = m.Proof
+		}
+	}
+	return view
+}
+
+func RenderCeremony(w http.ResponseWriter, r *http.Request, ceremony *models.Ceremony) {
+	RenderDataResponse(w, r, buildCeremonyView(ceremony))
+}
+
+func RenderCeremonies(w http.ResponseWriter, r *http.Request, ceremonies []*models.Ceremony) {
+	views := make([]*CeremonyView, len(ceremonies))
+	for i, c := range ceremonies {
+		views[i] = buildCeremonyView(c)
+	}
+	RenderDataResponse(w, r, views)
+}
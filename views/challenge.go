@@ -0,0 +1,40 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type ChallengeView struct {
+	ChallengeID string    `json:"challenge_id"`
+	SnapshotID  string    `json:"snapshot_id"`
+	Challenger  string    `json:"challenger"`
+	Custodian   string    `json:"custodian"`
+	Message     string    `json:"message"`
+	Signature   string    `json:"signature"`
+	State       string    `json:"state"`
+	Deadline    time.Time `json:"deadline"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func RenderChallenges(w http.ResponseWriter, r *http.Request, challenges []*models.Challenge) {
+	views := make([]*ChallengeView, len(challenges))
+	for i, c := range challenges {
+		views[i] = &ChallengeView{
+			ChallengeID: c.ChallengeID,
+			SnapshotID:  c.SnapshotID,
+			Challenger:  c.Challenger,
+			Custodian:   c.Custodian,
+			Message:     c.Message,
+			Signature:   c.Signature,
+			State:       c.State,
+			Deadline:    c.Deadline,
+			CreatedAt:   c.CreatedAt,
+			UpdatedAt:   c.UpdatedAt,
+		}
+	}
+	RenderDataResponse(w, r, views)
+}
@@ -0,0 +1,72 @@
+package views
+
+import (
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type CustodianApprovalView struct {
+	Approver  string    `json:"approver"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CustodianEpochView struct {
+	Epoch       int64                    `json:"epoch"`
+	Threshold   int                      `json:"threshold"`
+	GroupKey    string                   `json:"group_key"`
+	CeremonyID  string                   `json:"ceremony_id"`
+	State       string                   `json:"state"`
+	Members     []string                 `json:"members"`
+	Approvals   []*CustodianApprovalView `json:"approvals"`
+	Payload     string                   `json:"payload,omitempty"`
+	Transaction string                   `json:"transaction,omitempty"`
+	Deadline    time.Time                `json:"deadline"`
+	CreatedAt   time.Time                `json:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+}
+
+func buildCustodianEpochView(e *models.CustodianEpoch, update *extra.CustodianUpdate) *CustodianEpochView {
+	view := &CustodianEpochView{
+		Epoch:      e.Epoch,
+		Threshold:  e.Threshold,
+		GroupKey:   e.GroupKey,
+		CeremonyID: e.CeremonyID,
+		State:      e.State,
+		Members:    e.Members,
+		Approvals:  make([]*CustodianApprovalView, len(e.Approvals)),
+		Deadline:   e.Deadline(),
+		CreatedAt:  e.CreatedAt,
+		UpdatedAt:  e.UpdatedAt,
+	}
+	for i, a := range e.Approvals {
+		view.Approvals[i] = &CustodianApprovalView{
+			Approver:  a.Approver,
+			Signature: a.Signature,
+			CreatedAt: a.CreatedAt,
+		}
+	}
+	if update != nil {
+		view.Payload = hex.EncodeToString(update.Payload())
+		if e.State == models.CustodianEpochStateActive || e.State == models.CustodianEpochStateRetired {
+			view.Transaction = hex.EncodeToString(update.Encode())
+		}
+	}
+	return view
+}
+
+func RenderCustodianEpoch(w http.ResponseWriter, r *http.Request, epoch *models.CustodianEpoch, update *extra.CustodianUpdate) {
+	RenderDataResponse(w, r, buildCustodianEpochView(epoch, update))
+}
+
+func RenderCustodianEpochs(w http.ResponseWriter, r *http.Request, epochs []*models.CustodianEpoch) {
+	views := make([]*CustodianEpochView, len(epochs))
+	for i, e := range epochs {
+		views[i] = buildCustodianEpochView(e, nil)
+	}
+	RenderDataResponse(w, r, views)
+}
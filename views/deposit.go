@@ -0,0 +1,58 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type DepositView struct {
+	TransactionHash    string    `json:"transaction_hash"`
+	Payload            string    `json:"payload"`
+	Chain              string    `json:"chain"`
+	AssetKey           string    `json:"asset_key"`
+	DepositHash        string    `json:"deposit_hash"`
+	OutputIndex        uint64    `json:"output_index"`
+	Amount             string    `json:"amount"`
+	Source             string    `json:"source"`
+	State              string    `json:"state"`
+	DomainSignature    string    `json:"domain_signature"`
+	CustodianSignature string    `json:"custodian_signature"`
+	CustodianEpoch     int64     `json:"custodian_epoch"`
+	SnapshotHash       string    `json:"snapshot_hash"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func buildDepositView(d *models.Deposit) *DepositView {
+	return &DepositView{
+		TransactionHash:    d.TransactionHash,
+		Payload:            d.Payload,
+		Chain:              d.Chain,
+		AssetKey:           d.AssetKey,
+		DepositHash:        d.DepositHash,
+		OutputIndex:        d.OutputIndex,
+		Amount:             d.Amount,
+		Source:             d.Source,
+		State:              d.State,
+		DomainSignature:    d.DomainSignature,
+		CustodianSignature: d.CustodianSignature,
+		CustodianEpoch:     d.CustodianEpoch,
+		SnapshotHash:       d.SnapshotHash,
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
+	}
+}
+
+func RenderDeposit(w http.ResponseWriter, r *http.Request, deposit *models.Deposit) {
+	RenderDataResponse(w, r, buildDepositView(deposit))
+}
+
+func RenderDeposits(w http.ResponseWriter, r *http.Request, deposits []*models.Deposit) {
+	views := make([]*DepositView, len(deposits))
+	for i, d := range deposits {
+		views[i] = buildDepositView(d)
+	}
+	RenderDataResponse(w, r, views)
+}
@@ -0,0 +1,31 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type RegistrationEpochView struct {
+	Name       string    `json:"name"`
+	Open       bool      `json:"open"`
+	OpenAt     time.Time `json:"open_at"`
+	CloseAt    time.Time `json:"close_at"`
+	Seats      int       `json:"seats"`
+	Registered int       `json:"registered"`
+}
+
+func RenderRegistrationEpoch(w http.ResponseWriter, r *http.Request, epoch *models.RegistrationEpoch) {
+	view := &RegistrationEpochView{
+		Open:       epoch.Open,
+		Registered: epoch.Registered,
+	}
+	if e := epoch.Epoch; e != nil {
+		view.Name = e.Name
+		view.OpenAt = e.Open
+		view.CloseAt = e.Close
+		view.Seats = e.Seats
+	}
+	RenderDataResponse(w, r, view)
+}
@@ -0,0 +1,71 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type KernelNodeView struct {
+	NodeID      string    `json:"node_id"`
+	Signer      string    `json:"signer"`
+	Payee       string    `json:"payee"`
+	State       string    `json:"state"`
+	Timestamp   int64     `json:"timestamp"`
+	Transaction string    `json:"transaction"`
+	Custodian   string    `json:"custodian"`
+	SafeState   string    `json:"safe_state"`
+	Detached    bool      `json:"detached"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type KernelNodeEventView struct {
+	Signer    string    `json:"signer"`
+	Payee     string    `json:"payee"`
+	State     string    `json:"state"`
+	Timestamp int64     `json:"timestamp"`
+	Changes   []string  `json:"changes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func RenderKernelNodes(w http.ResponseWriter, r *http.Request, nodes []*models.KernelNode, safe map[string]*models.Node) {
+	views := make([]*KernelNodeView, len(nodes))
+	for i, n := range nodes {
+		view := &KernelNodeView{
+			NodeID:      n.NodeID,
+			Signer:      n.Signer,
+			Payee:       n.Payee,
+			State:       n.State,
+			Timestamp:   n.Timestamp,
+			Transaction: n.Transaction,
+			UpdatedAt:   n.UpdatedAt,
+		}
+		if s := safe[n.NodeID]; s != nil {
+			view.Custodian = s.Custodian
+			view.SafeState = s.State
+			view.Detached = n.State != models.KernelNodeStateAccepted
+		}
+		views[i] = view
+	}
+	RenderDataResponse(w, r, views)
+}
+
+func RenderKernelNodeEvents(w http.ResponseWriter, r *http.Request, events []*models.KernelNodeEvent) {
+	views := make([]*KernelNodeEventView, len(events))
+	for i, e := range events {
+		var prev *models.KernelNodeEvent
+		if i > 0 {
+			prev = events[i-1]
+		}
+		views[i] = &KernelNodeEventView{
+			Signer:    e.Signer,
+			Payee:     e.Payee,
+			State:     e.State,
+			Timestamp: e.Timestamp,
+			Changes:   e.Changes(prev),
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	RenderDataResponse(w, r, views)
+}
@@ -0,0 +1,45 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type LivenessWeekView struct {
+	Week             string    `json:"week"`
+	Start            time.Time `json:"start"`
+	KernelSignatures int       `json:"kernel_signatures"`
+	SafeSignatures   int       `json:"safe_signatures"`
+	Complete         bool      `json:"complete"`
+	Missed           bool      `json:"missed"`
+}
+
+type NodeLivenessView struct {
+	Custodian   string              `json:"custodian"`
+	MissedWeeks []string            `json:"missed_weeks"`
+	Weeks       []*LivenessWeekView `json:"weeks"`
+}
+
+func RenderNodeLiveness(w http.ResponseWriter, r *http.Request, node *models.Node, weeks []*models.LivenessWeek) {
+	view := &NodeLivenessView{
+		Custodian:   node.Custodian,
+		MissedWeeks: []string{},
+		Weeks:       make([]*LivenessWeekView, len(weeks)),
+	}
+	for i, l := range weeks {
+		view.Weeks[i] = &LivenessWeekView{
+			Week:             l.Week,
+			Start:            l.Start,
+			KernelSignatures: l.KernelSignatures,
+			SafeSignatures:   l.SafeSignatures,
+			Complete:         l.Complete,
+			Missed:           l.Missed,
+		}
+		if l.Missed {
+			view.MissedWeeks = append(view.MissedWeeks, l.Week)
+		}
+	}
+	RenderDataResponse(w, r, view)
+}
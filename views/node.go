@@ -15,6 +15,7 @@ type NodeView struct {
 	MixinHash string    `json:"mixin_hash"`
 	Keystore  string    `json:"keystore"`
 	PublicKey string    `json:"public_key"`
+	State     string    `json:"state"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -28,6 +29,7 @@ func buildNodeView(n *models.Node) *NodeView {
 		MixinHash: n.MixinHash.String,
 		Keystore:  n.Keystore,
 		PublicKey: n.PublicKey,
+		State:     n.State,
 		CreatedAt: n.CreatedAt,
 		UpdatedAt: n.UpdatedAt,
 	}
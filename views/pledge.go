@@ -0,0 +1,45 @@
+package views
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/extra"
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type PledgeNodeView struct {
+	Custodian string `json:"custodian"`
+	Payee     string `json:"payee"`
+	KernelID  string `json:"kernel_id"`
+	State     string `json:"state"`
+}
+
+type PledgeView struct {
+	Hash      string            `json:"hash"`
+	NetworkID string            `json:"network_id"`
+	Extra     string            `json:"extra"`
+	Nodes     []*PledgeNodeView `json:"nodes"`
+}
+
+func BuildPledgeView(pledge *extra.Pledge, nodes []*models.Node) *PledgeView {
+	view := &PledgeView{
+		Hash:      pledge.Hash().String(),
+		NetworkID: pledge.NetworkID.String(),
+		Extra:     hex.EncodeToString(pledge.Encode()),
+		Nodes:     make([]*PledgeNodeView, len(nodes)),
+	}
+	for i, n := range nodes {
+		view.Nodes[i] = &PledgeNodeView{
+			Custodian: n.Custodian,
+			Payee:     n.Payee,
+			KernelID:  n.KernelID,
+			State:     n.State,
+		}
+	}
+	return view
+}
+
+func RenderPledge(w http.ResponseWriter, r *http.Request, pledge *extra.Pledge, nodes []*models.Node) {
+	RenderDataResponse(w, r, BuildPledgeView(pledge, nodes))
+}
@@ -0,0 +1,42 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type RefundView struct {
+	TraceID    string    `json:"trace_id"`
+	SnapshotID string    `json:"snapshot_id"`
+	OpponentID string    `json:"opponent_id"`
+	AssetID    string    `json:"asset_id"`
+	Amount     string    `json:"amount"`
+	Reason     string    `json:"reason"`
+	State      string    `json:"state"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func buildRefundView(r *models.Refund) *RefundView {
+	return &RefundView{
+		TraceID:    r.TraceID,
+		SnapshotID: r.SnapshotID,
+		OpponentID: r.OpponentID,
+		AssetID:    r.AssetID,
+		Amount:     r.Amount,
+		Reason:     r.Reason,
+		State:      r.State,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+}
+
+func RenderRefunds(w http.ResponseWriter, r *http.Request, refunds []*models.Refund) {
+	views := make([]*RefundView, len(refunds))
+	for i, refund := range refunds {
+		views[i] = buildRefundView(refund)
+	}
+	RenderDataResponse(w, r, views)
+}
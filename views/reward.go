@@ -0,0 +1,53 @@
+package views
+
+import (
+	"net/http"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type RewardPayoutView struct {
+	Payee              string `json:"payee"`
+	Custodian          string `json:"custodian"`
+	KernelSignatures   int64  `json:"kernel_signatures"`
+	SafeSignatures     int64  `json:"safe_signatures"`
+	ChallengesAnswered int64  `json:"challenges_answered"`
+	ChallengesMissed   int64  `json:"challenges_missed"`
+	Weight             int64  `json:"weight"`
+	Amount             string `json:"amount"`
+}
+
+type RewardTableView struct {
+	Day            string              `json:"day"`
+	Mint           string              `json:"mint"`
+	CustodianShare string              `json:"custodian_share"`
+	TotalWeight    int64               `json:"total_weight"`
+	Payouts        []*RewardPayoutView `json:"payouts"`
+}
+
+func BuildRewardTableView(table *models.RewardTable) *RewardTableView {
+	view := &RewardTableView{
+		Day:            table.Day.Format("2006-01-02"),
+		Mint:           table.Mint,
+		CustodianShare: table.CustodianShare,
+		TotalWeight:    table.TotalWeight,
+		Payouts:        make([]*RewardPayoutView, len(table.Payouts)),
+	}
+	for i, p := range table.Payouts {
+		view.Payouts[i] = &RewardPayoutView{
+			Payee:              p.Payee,
+			Custodian:          p.Custodian,
+			KernelSignatures:   p.KernelSignatures,
+			SafeSignatures:     p.SafeSignatures,
+			ChallengesAnswered: p.ChallengesAnswered,
+			ChallengesMissed:   p.ChallengesMissed,
+			Weight:             p.Weight,
+			Amount:             p.Amount,
+		}
+	}
+	return view
+}
+
+func RenderRewardTable(w http.ResponseWriter, r *http.Request, table *models.RewardTable) {
+	RenderDataResponse(w, r, BuildRewardTableView(table))
+}
@@ -0,0 +1,94 @@
+package views
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/safe/governance/models"
+)
+
+type EvidenceView struct {
+	EvidenceID string    `json:"evidence_id"`
+	Type       string    `json:"type"`
+	Custodian  string    `json:"custodian"`
+	Reporter   string    `json:"reporter"`
+	Payload    string    `json:"payload"`
+	Signature  string    `json:"signature"`
+	Verified   bool      `json:"verified"`
+	ProposalID string    `json:"proposal_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type SlashApprovalView struct {
+	Approver  string    `json:"approver"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SlashProposalView struct {
+	ProposalID string               `json:"proposal_id"`
+	Custodian  string               `json:"custodian"`
+	State      string               `json:"state"`
+	Threshold  int                  `json:"threshold"`
+	Approvals  []*SlashApprovalView `json:"approvals"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+func buildEvidenceView(e *models.Evidence) *EvidenceView {
+	return &EvidenceView{
+		EvidenceID: e.EvidenceID,
+		Type:       e.Type,
+		Custodian:  e.Custodian,
+		Reporter:   e.Reporter,
+		Payload:    e.Payload,
+		Signature:  e.Signature,
+		Verified:   e.Verified,
+		ProposalID: e.ProposalID.String,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func buildSlashProposalView(p *models.SlashProposal) *SlashProposalView {
+	view := &SlashProposalView{
+		ProposalID: p.ProposalID,
+		Custodian:  p.Custodian,
+		State:      p.State,
+		Threshold:  p.Threshold,
+		Approvals:  make([]*SlashApprovalView, len(p.Approvals)),
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+	for i, a := range p.Approvals {
+		view.Approvals[i] = &SlashApprovalView{
+			Approver:  a.Approver,
+			Signature: a.Signature,
+			CreatedAt: a.CreatedAt,
+		}
+	}
+	return view
+}
+
+func RenderEvidence(w http.ResponseWriter, r *http.Request, evidence *models.Evidence) {
+	RenderDataResponse(w, r, buildEvidenceView(evidence))
+}
+
+func RenderEvidences(w http.ResponseWriter, r *http.Request, evidences []*models.Evidence) {
+	views := make([]*EvidenceView, len(evidences))
+	for i, e := range evidences {
+		views[i] = buildEvidenceView(e)
+	}
+	RenderDataResponse(w, r, views)
+}
+
+func RenderSlashProposal(w http.ResponseWriter, r *http.Request, proposal *models.SlashProposal) {
+	RenderDataResponse(w, r, buildSlashProposalView(proposal))
+}
+
+func RenderSlashProposals(w http.ResponseWriter, r *http.Request, proposals []*models.SlashProposal) {
+	views := make([]*SlashProposalView, len(proposals))
+	for i, p := range proposals {
+		views[i] = buildSlashProposalView(p)
+	}
+	RenderDataResponse(w, r, views)
+}